@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stannisl/ai-browser-assistant/internal/browser"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// pickTab выбирает вкладку подключённого браузера, которой будет управлять агент.
+// query — номер вкладки или часть URL/заголовка из --tab; если он пуст и вкладок
+// несколько, вкладка спрашивается у пользователя.
+func pickTab(ctx context.Context, mgr *browser.Manager, query string, scanner *bufio.Scanner) error {
+	tabs, err := mgr.ListTabs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(tabs) == 0 {
+		return nil
+	}
+
+	if query != "" {
+		id, ok := findTab(tabs, query)
+		if !ok {
+			return fmt.Errorf("вкладка %q не найдена", query)
+		}
+		return mgr.SwitchTab(ctx, id)
+	}
+	if len(tabs) == 1 {
+		return nil
+	}
+
+	fmt.Println("🗂️  Открытые вкладки:")
+	for _, t := range tabs {
+		fmt.Printf("  [%d] %s — %s\n", t.ID, t.Title, t.URL)
+	}
+	for {
+		fmt.Printf("Какой вкладкой управлять? [%d]: ", tabs[0].ID)
+		if !scanner.Scan() {
+			return nil
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			return mgr.SwitchTab(ctx, tabs[0].ID)
+		}
+		if id, ok := findTab(tabs, answer); ok {
+			return mgr.SwitchTab(ctx, id)
+		}
+		fmt.Println("❌ Нет такой вкладки")
+	}
+}
+
+// findTab ищет вкладку по номеру, затем по части URL или заголовка
+func findTab(tabs []types.TabInfo, query string) (int, bool) {
+	if id, err := strconv.Atoi(query); err == nil {
+		for _, t := range tabs {
+			if t.ID == id {
+				return id, true
+			}
+		}
+		return 0, false
+	}
+
+	q := strings.ToLower(query)
+	for _, t := range tabs {
+		if strings.Contains(strings.ToLower(t.URL), q) || strings.Contains(strings.ToLower(t.Title), q) {
+			return t.ID, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stannisl/ai-browser-assistant/internal/browser"
+	"github.com/stannisl/ai-browser-assistant/internal/llm"
+	"github.com/stannisl/ai-browser-assistant/internal/logger"
+	"github.com/stannisl/ai-browser-assistant/internal/pool"
+	"github.com/stannisl/ai-browser-assistant/internal/profiles"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// batchResult — строка JSON Lines с итогом одной задачи пакета
+type batchResult struct {
+	Line    int             `json:"line"`
+	Task    string          `json:"task"`
+	Success bool            `json:"success"`
+	Message string          `json:"message,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Steps   int             `json:"steps,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+type batchTask struct {
+	line      int
+	prompt    string
+	emulation *types.Emulation
+}
+
+// batchLine — задача в виде JSON-строки, когда ей нужны свои настройки эмуляции
+type batchLine struct {
+	Task      string           `json:"task"`
+	Emulation *types.Emulation `json:"emulation"`
+}
+
+// runBatch выполняет задачи из файла (по одной в строке, # — комментарий) в пуле браузеров.
+// Строка, начинающаяся с {, — JSON {"task": ..., "emulation": {...}}.
+// Итоги пишутся в stdout в порядке завершения, ход выполнения — в stderr.
+// Воркеры делят один stdin, поэтому вопросы пользователю отключены, а подтверждения — отказ.
+func runBatch(ctx context.Context, path string, cfg pool.Config, schema json.RawMessage, llmClient *llm.Client, siteProfiles *profiles.Registry, log *logger.Logger) error {
+	tasks, err := readBatch(path)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return fmt.Errorf("в %s нет задач", path)
+	}
+
+	cfg.Agent.NonInteractive = true
+	logger.ToStderr()
+
+	p, err := pool.New(cfg, llmClient, siteProfiles, log)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	fmt.Fprintf(os.Stderr, "📋 Задач: %d, параллельно: %d (%s)\n", len(tasks), p.Stats().Size, cfg.Isolation)
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		done   atomic.Int32
+		failed atomic.Int32
+	)
+	enc := json.NewEncoder(os.Stdout)
+
+	for _, t := range tasks {
+		wg.Add(1)
+		go func(t batchTask) {
+			defer wg.Done()
+
+			res := batchResult{Line: t.line, Task: t.prompt}
+			result, err := p.Run(ctx, types.Task{Prompt: t.prompt, OutputSchema: schema, Emulation: t.emulation})
+			if result != nil {
+				res.Success = result.Success
+				res.Message = result.Message
+				res.Data = result.Data
+				res.Steps = result.Steps
+			}
+			if err != nil {
+				res.Success = false
+				res.Error = err.Error()
+			}
+			if !res.Success {
+				failed.Add(1)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err := enc.Encode(res); err != nil {
+				log.Error("Ошибка записи результата", err)
+			}
+			n := done.Add(1)
+			mark := "✅"
+			if !res.Success {
+				mark = "❌"
+			}
+			fmt.Fprintf(os.Stderr, "%s [%d/%d] строка %d\n", mark, n, len(tasks), t.line)
+		}(t)
+	}
+	wg.Wait()
+
+	stats := p.Stats()
+	fmt.Fprintf(os.Stderr, "🏁 Готово: %d, с ошибкой: %d, перезапусков браузера: %d\n", len(tasks), failed.Load(), stats.Recycled)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return nil
+}
+
+func readBatch(path string) ([]batchTask, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл задач: %w", err)
+	}
+	defer f.Close()
+
+	var tasks []batchTask
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		prompt := strings.TrimSpace(scanner.Text())
+		if prompt == "" || strings.HasPrefix(prompt, "#") {
+			continue
+		}
+		task := batchTask{line: line, prompt: prompt}
+		if strings.HasPrefix(prompt, "{") {
+			var l batchLine
+			if err := json.Unmarshal([]byte(prompt), &l); err != nil {
+				return nil, fmt.Errorf("строка %d: неверный JSON: %w", line, err)
+			}
+			if strings.TrimSpace(l.Task) == "" {
+				return nil, fmt.Errorf("строка %d: пустое поле task", line)
+			}
+			if err := browser.ValidateEmulation(l.Emulation); err != nil {
+				return nil, fmt.Errorf("строка %d: %w", line, err)
+			}
+			task.prompt, task.emulation = l.Task, l.Emulation
+		}
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл задач: %w", err)
+	}
+	return tasks, nil
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/stannisl/ai-browser-assistant/internal/browser"
+	"github.com/stannisl/ai-browser-assistant/internal/logger"
+	"github.com/stannisl/ai-browser-assistant/internal/session"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+const sessionUsage = `Использование:
+  agent session export [--domain mail.ru]... [--encrypt] [--out FILE] [--profile NAME | --user-data DIR | --attach URL]
+  agent session import [--profile NAME | --user-data DIR] FILE
+
+export пишет cookies, localStorage и sessionStorage в JSON (в stdout, если нет --out).
+sessionStorage живёт только в открытых вкладках, поэтому попадает в файл лишь с --attach.
+import загружает их в профиль браузера. Парольная фраза шифрования — SESSION_PASSPHRASE.`
+
+// domainList — значение флага --domain: можно повторять и перечислять через запятую
+type domainList []string
+
+func (d *domainList) String() string {
+	return strings.Join(*d, ",")
+}
+
+func (d *domainList) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*d = append(*d, part)
+		}
+	}
+	return nil
+}
+
+// runSession выполняет команды переноса сессии и возвращает код выхода
+func runSession(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, sessionUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "export":
+		return sessionExport(args[1:])
+	case "import":
+		return sessionImport(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "❌ Неизвестная команда session %s\n\n%s\n", args[0], sessionUsage)
+		return 2
+	}
+}
+
+func sessionExport(args []string) int {
+	fs := flag.NewFlagSet("session export", flag.ContinueOnError)
+	var domains domainList
+	fs.Var(&domains, "domain", "Export only these domains and their subdomains (repeatable, comma-separated)")
+	userData := addUserDataFlags(fs)
+	outPath := fs.String("out", "", "Write the session to a file instead of stdout")
+	encrypt := fs.Bool("encrypt", false, "Encrypt the session with SESSION_PASSPHRASE")
+	attachURL := fs.String("attach", os.Getenv("BROWSER_URL"), "Export from a running Chrome, including sessionStorage of its open tabs")
+	debug := fs.Bool("debug", os.Getenv("DEBUG") == "true", "Enable debug logging")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// У подключённого браузера свой профиль и прокси
+	var userDataDir, proxy string
+	if *attachURL == "" {
+		var err error
+		if userDataDir, err = userData.resolve(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		if proxy, err = userData.proxy(os.Getenv("BROWSER_PROXY")); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+	}
+
+	passphrase := ""
+	if *encrypt {
+		passphrase = os.Getenv("SESSION_PASSPHRASE")
+		if passphrase == "" {
+			fmt.Fprintln(os.Stderr, "❌ Для --encrypt задайте парольную фразу в SESSION_PASSPHRASE")
+			return 1
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	mgr, log, err := launchSessionBrowser(ctx, userDataDir, proxy, *attachURL, *debug)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Ошибка запуска браузера: %v\n", err)
+		return 1
+	}
+	defer log.Close()
+	defer mgr.Close()
+
+	state, err := mgr.ExportSession(ctx, domains)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Ошибка экспорта сессии: %v\n", err)
+		return 1
+	}
+
+	data, err := session.Encode(state, passphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Ошибка сохранения сессии: %v\n", err)
+		return 1
+	}
+
+	if *outPath == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		// В файле токены входа — читать его должен только владелец
+		err = os.WriteFile(*outPath, data, 0o600)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Ошибка записи сессии: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Сессия экспортирована: %d cookies, %d origin\n", len(state.Cookies), len(state.Origins))
+	if !mgr.Attached() {
+		fmt.Fprintln(os.Stderr, "ℹ️  sessionStorage не экспортирован: он есть только в открытых вкладках — экспортируйте из работающего браузера с --attach")
+	}
+	return 0
+}
+
+func sessionImport(args []string) int {
+	fs := flag.NewFlagSet("session import", flag.ContinueOnError)
+	userData := addUserDataFlags(fs)
+	debug := fs.Bool("debug", os.Getenv("DEBUG") == "true", "Enable debug logging")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, sessionUsage)
+		return 2
+	}
+	userDataDir, err := userData.resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	proxy, err := userData.proxy(os.Getenv("BROWSER_PROXY"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	state, err := loadSession(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	mgr, log, err := launchSessionBrowser(ctx, userDataDir, proxy, "", *debug)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Ошибка запуска браузера: %v\n", err)
+		return 1
+	}
+	defer log.Close()
+	defer mgr.Close()
+
+	if err := mgr.ImportSession(ctx, state); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Ошибка импорта сессии: %v\n", err)
+		return 1
+	}
+	if domains, err := mgr.LoginDomains(); err == nil {
+		_ = userData.touch(domains)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Сессия импортирована в %s: %d cookies, %d origin\n", userDataDir, len(state.Cookies), len(state.Origins))
+	for _, o := range state.Origins {
+		if len(o.SessionStorage) > 0 {
+			fmt.Fprintln(os.Stderr, "ℹ️  sessionStorage не сохраняется в профиле: чтобы применить его, запустите агента с --session")
+			break
+		}
+	}
+	return 0
+}
+
+// loadSession читает файл сессии ("-" — stdin). Зашифрованный файл расшифровывается
+// парольной фразой из SESSION_PASSPHRASE.
+func loadSession(path string) (*types.SessionState, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать сессию: %w", err)
+	}
+
+	state, err := session.Decode(data, os.Getenv("SESSION_PASSPHRASE"))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить сессию %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// launchSessionBrowser запускает браузер без окна на профиле для экспорта или импорта сессии.
+// С remoteURL подключается к уже запущенному браузеру.
+func launchSessionBrowser(ctx context.Context, userDataDir, proxy, remoteURL string, debug bool) (*browser.Manager, *logger.Logger, error) {
+	log, err := logger.New(debug)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create logger: %w", err)
+	}
+
+	mgr := browser.NewManager(&types.BrowserConfig{
+		UserDataDir: userDataDir,
+		Headless:    true,
+		Timeout:     30 * time.Second,
+		Debug:       debug,
+		Proxy:       proxy,
+		RemoteURL:   remoteURL,
+	}, log)
+	if err := mgr.Launch(ctx); err != nil {
+		log.Close()
+		return nil, nil, err
+	}
+	return mgr, log, nil
+}
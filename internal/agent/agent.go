@@ -2,11 +2,15 @@ package agent
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
 
+	"github.com/stannisl/ai-browser-assistant/internal/artifacts"
 	"github.com/stannisl/ai-browser-assistant/internal/browser"
 	"github.com/stannisl/ai-browser-assistant/internal/extractor"
 	"github.com/stannisl/ai-browser-assistant/internal/llm"
@@ -26,6 +30,18 @@ type Agent struct {
 	lastToolName  string
 	lastToolArgs  string
 	sameToolCount int
+
+	// Схема результата текущей задачи и итог, выставляемый report
+	outputSchema   json.RawMessage
+	result         *types.RunResult
+	reportAttempts int
+
+	// Профили сайтов, подсказки которых уже показаны модели
+	shownHints map[string]bool
+
+	// Артефакты текущей задачи и изображения для следующего сообщения модели
+	artifacts     *artifacts.Run
+	pendingImages []openai.ChatMessagePart
 }
 
 func New(
@@ -44,7 +60,28 @@ func New(
 	}
 }
 
-func (a *Agent) Run(ctx context.Context, task string) error {
+func (a *Agent) Run(ctx context.Context, task types.Task) (*types.RunResult, error) {
+	prompt := task.Prompt
+	if len(task.OutputSchema) > 0 {
+		if !json.Valid(task.OutputSchema) {
+			return nil, fmt.Errorf("output schema is not valid JSON")
+		}
+		prompt += fmt.Sprintf(llm.OutputSchemaPrompt, task.OutputSchema)
+	}
+
+	// Эмуляция задачи действует до её конца, потом возвращается эмуляция конфигурации
+	if task.Emulation != nil {
+		restore, err := a.browser.OverrideEmulation(task.Emulation)
+		if err != nil {
+			return nil, fmt.Errorf("apply task emulation: %w", err)
+		}
+		defer func() {
+			if err := restore(); err != nil {
+				a.logger.Warn("Failed to restore emulation", "error", err.Error())
+			}
+		}()
+	}
+
 	a.step = 0
 	a.messages = []openai.ChatCompletionMessage{
 		{
@@ -53,17 +90,31 @@ func (a *Agent) Run(ctx context.Context, task string) error {
 		},
 		{
 			Role:    openai.ChatMessageRoleUser,
-			Content: task,
+			Content: prompt,
 		},
 	}
 	a.lastToolName = ""
 	a.lastToolArgs = ""
 	a.sameToolCount = 0
+	a.outputSchema = task.OutputSchema
+	a.result = nil
+	a.reportAttempts = 0
+	a.shownHints = map[string]bool{}
+	a.artifacts = artifacts.NewRun(a.config.ArtifactsDir, time.Now())
+	defer a.artifacts.Prune()
+	if err := a.browser.SetDownloadDir(filepath.Join(a.artifacts.Dir(), "downloads")); err != nil {
+		a.logger.Warn("Failed to set downloads directory", "error", err.Error())
+	}
+	// Трафик задачи сохраняется рядом с её скриншотами; defer выполнится раньше Prune
+	a.browser.StartHAR()
+	defer a.saveHAR()
+	a.pendingImages = nil
+	a.extractor.Reset()
 
 	for a.step < a.config.MaxSteps {
 		select {
 		case <-ctx.Done():
-			return types.ErrContextCanceled
+			return nil, types.ErrContextCanceled
 		default:
 		}
 
@@ -76,7 +127,7 @@ func (a *Agent) Run(ctx context.Context, task string) error {
 		// Запрос к LLM
 		response, err := a.llm.Chat(ctx, a.messages)
 		if err != nil {
-			return fmt.Errorf("llm chat: %w", err)
+			return nil, fmt.Errorf("llm chat: %w", err)
 		}
 
 		// Извлекаем tool call
@@ -139,16 +190,74 @@ func (a *Agent) Run(ctx context.Context, task string) error {
 			Content:    toolResultContent,
 		})
 
-		// Если report — завершаем
-		if toolCall.ToolName == "report" {
-			return nil
+		// Изображения нельзя положить в tool message — отправляем их следующим сообщением пользователя
+		a.flushImages()
+
+		// Если report принят — завершаем
+		if a.result != nil {
+			a.result.Steps = a.step
+			return a.result, nil
 		}
+	}
+
+	return nil, types.ErrMaxStepsExceeded
+}
 
-		// Небольшая пауза между шагами
-		time.Sleep(200 * time.Millisecond)
+// saveHAR сохраняет записанный трафик задачи в network.har, если запись включена
+func (a *Agent) saveHAR() {
+	data, err := a.browser.StopHAR()
+	if err != nil {
+		a.logger.Warn("Failed to build HAR", "error", err.Error())
+		return
+	}
+	if data == nil {
+		return
+	}
+	path, err := a.artifacts.Save("network.har", data)
+	if err != nil {
+		a.logger.Warn("Failed to save HAR", "error", err.Error())
+		return
+	}
+	a.logger.Info("Network traffic saved", "path", path)
+}
+
+// attachImage добавляет PNG к следующему сообщению модели
+func (a *Agent) attachImage(data []byte, caption string) {
+	a.pendingImages = append(a.pendingImages,
+		openai.ChatMessagePart{Type: openai.ChatMessagePartTypeText, Text: caption},
+		openai.ChatMessagePart{
+			Type: openai.ChatMessagePartTypeImageURL,
+			ImageURL: &openai.ChatMessageImageURL{
+				URL:    "data:image/png;base64," + base64.StdEncoding.EncodeToString(data),
+				Detail: openai.ImageURLDetailAuto,
+			},
+		},
+	)
+}
+
+// flushImages отправляет накопленные изображения. Старые снимки из истории убираются:
+// модели нужен только актуальный вид страницы, а каждое изображение занимает много контекста.
+func (a *Agent) flushImages() {
+	if len(a.pendingImages) == 0 {
+		return
+	}
+
+	for i := range a.messages {
+		for j, part := range a.messages[i].MultiContent {
+			if part.Type == openai.ChatMessagePartTypeImageURL {
+				a.messages[i].MultiContent[j] = openai.ChatMessagePart{
+					Type: openai.ChatMessagePartTypeText,
+					Text: "(older screenshot removed)",
+				}
+			}
+		}
 	}
 
-	return types.ErrMaxStepsExceeded
+	a.messages = append(a.messages, openai.ChatCompletionMessage{
+		Role:         openai.ChatMessageRoleUser,
+		MultiContent: a.pendingImages,
+	})
+	a.pendingImages = nil
 }
 
 func (a *Agent) detectLoop(tc *types.ToolCall) bool {
@@ -3,23 +3,90 @@ package agent
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/stannisl/ai-browser-assistant/internal/extractor"
+	"github.com/stannisl/ai-browser-assistant/internal/llm"
+	"github.com/stannisl/ai-browser-assistant/internal/schema"
 	"github.com/stannisl/ai-browser-assistant/internal/types"
 )
 
+// defaultSchemaAttempts — попыток подогнать данные под схему, если AgentConfig.SchemaAttempts не задан
+const defaultSchemaAttempts = 3
+
+// stdinMu не даёт агентам пула задавать вопросы пользователю одновременно:
+// вопрос и ответ одной задачи не должны перемешаться с другой
+var stdinMu sync.Mutex
+
+// dialogFreeTools — инструменты, которые работают, пока вкладку блокирует JS-диалог
+var dialogFreeTools = map[string]bool{
+	"extract_page":   true,
+	"handle_dialog":  true,
+	"list_tabs":      true,
+	"switch_tab":     true,
+	"open_tab":       true,
+	"close_tab":      true,
+	"list_downloads": true,
+	"wait":           true,
+	"ask_user":       true,
+	"confirm_action": true,
+	"report":         true,
+}
+
 // ExecuteTool выполняет инструмент и возвращает результат
 func (a *Agent) ExecuteTool(ctx context.Context, tc *types.ToolCall) (string, error) {
+	// Пока открыт диалог, страница не выполняет скрипты: действие ждало бы до таймаута
+	if d := a.browser.PendingDialog(); d != nil && !dialogFreeTools[tc.ToolName] {
+		return fmt.Sprintf("Error: a %s dialog %q blocks the page. Answer it with handle_dialog first.", d.Type, d.Message), nil
+	}
+
 	switch tc.ToolName {
 	case "extract_page":
-		return a.executeExtractPage(ctx)
+		return a.executeExtractPage(ctx, tc.Arguments)
+	case "read_page":
+		return a.executeReadPage(ctx, tc.Arguments)
+	case "extract_table":
+		return a.executeExtractTable(ctx, tc.Arguments)
+	case "extract_structured":
+		return a.executeExtractStructured(ctx, tc.Arguments)
+	case "screenshot":
+		return a.executeScreenshot(ctx, tc.Arguments)
 	case "navigate":
 		return a.executeNavigate(ctx, tc.Arguments)
+	case "go_back":
+		return a.executeGoBack(ctx, tc.Arguments)
+	case "go_forward":
+		return a.executeGoForward(ctx, tc.Arguments)
+	case "reload":
+		return a.executeReload(ctx, tc.Arguments)
+	case "list_tabs":
+		return a.executeListTabs(ctx, tc.Arguments)
+	case "switch_tab":
+		return a.executeSwitchTab(ctx, tc.Arguments)
+	case "open_tab":
+		return a.executeOpenTab(ctx, tc.Arguments)
+	case "close_tab":
+		return a.executeCloseTab(ctx, tc.Arguments)
 	case "click":
 		return a.executeClick(ctx, tc.Arguments)
+	case "hover":
+		return a.executeHover(ctx, tc.Arguments)
+	case "select_option":
+		return a.executeSelectOption(ctx, tc.Arguments)
+	case "set_checked":
+		return a.executeSetChecked(ctx, tc.Arguments)
+	case "upload_file":
+		return a.executeUploadFile(ctx, tc.Arguments)
+	case "list_downloads":
+		return a.executeListDownloads(ctx, tc.Arguments)
 	case "type_text":
 		return a.executeTypeText(ctx, tc.Arguments)
 	case "scroll":
@@ -28,6 +95,8 @@ func (a *Agent) ExecuteTool(ctx context.Context, tc *types.ToolCall) (string, er
 		return a.executeWait(ctx, tc.Arguments)
 	case "press_key":
 		return a.executePressKey(ctx, tc.Arguments)
+	case "handle_dialog":
+		return a.executeHandleDialog(ctx, tc.Arguments)
 	case "ask_user":
 		return a.executeAskUser(ctx, tc.Arguments)
 	case "confirm_action":
@@ -39,14 +108,229 @@ func (a *Agent) ExecuteTool(ctx context.Context, tc *types.ToolCall) (string, er
 	}
 }
 
-func (a *Agent) executeExtractPage(ctx context.Context) (string, error) {
+func (a *Agent) executeExtractPage(ctx context.Context, args map[string]interface{}) (string, error) {
+	full, _ := args["full"].(bool)
+
+	if dialog := a.browser.PendingDialog(); dialog != nil {
+		state := &types.PageState{
+			Title:  a.browser.GetTitle(),
+			URL:    a.browser.GetURL(),
+			Dialog: dialog,
+		}
+		state.Tab, state.TabCount = a.browser.ActiveTab()
+		return a.extractor.FormatDialogForLLM(state), nil
+	}
+
 	a.extractor.UpdatePage(a.browser.GetPage())
 
+	prev := a.extractor.LastState()
 	state, err := a.extractor.Extract(ctx)
 	if err != nil {
 		return fmt.Sprintf("Error extracting page: %v", err), nil
 	}
-	return a.extractor.FormatForLLM(state), nil
+	state.Tab, state.TabCount = a.browser.ActiveTab()
+	if history, err := a.browser.History(); err == nil {
+		state.History = history
+	}
+
+	marks := a.attachMarks(ctx, state)
+
+	// На той же странице отдаём только изменения, если они меньше полного списка.
+	// После перезагрузки документа сравнивать не с чем, даже если URL тот же.
+	if !full && prev != nil && !state.NewDocument && prev.URL == state.URL && prev.Tab.ID == state.Tab.ID {
+		diff := extractor.Diff(prev, state)
+		if diff.Size() <= len(state.Elements)/2 {
+			return a.extractor.FormatDiffForLLM(state, diff) + a.siteHints(state.URL) + marks, nil
+		}
+	}
+
+	return a.extractor.FormatForLLM(state) + a.siteHints(state.URL) + marks, nil
+}
+
+// attachMarks прикладывает к списку элементов скриншот, на котором элементы обведены
+// рамками с их ID (set-of-marks). Возвращает пояснение для результата extract_page
+// или пустую строку, если модель не принимает изображения или снимок не удался.
+func (a *Agent) attachMarks(ctx context.Context, state *types.PageState) string {
+	if !a.llm.SupportsVision() || len(state.Elements) == 0 {
+		return ""
+	}
+
+	shot, err := a.browser.Screenshot(ctx, -1)
+	if err != nil {
+		a.logger.Warn("Failed to take screenshot for element marks", "error", err.Error())
+		return ""
+	}
+
+	marked, err := extractor.DrawMarks(shot, state)
+	if err != nil {
+		a.logger.Warn("Failed to draw element marks", "error", err.Error())
+		return ""
+	}
+
+	if _, err := a.artifacts.Save(fmt.Sprintf("step-%02d-marks.png", a.step), marked); err != nil {
+		a.logger.Warn("Failed to save screenshot", "error", err.Error())
+	}
+
+	a.attachImage(marked, "Screenshot of the visible page. Boxes are labeled with element IDs from extract_page:")
+
+	return "\n(A screenshot with visible elements boxed and labeled by ID is attached to the next message. Use these IDs with click and type_text.)\n"
+}
+
+// siteHints возвращает подсказки профилей сайта, которые ещё не показывались в этой задаче
+func (a *Agent) siteHints(url string) string {
+	var b strings.Builder
+	for _, p := range a.extractor.MatchProfiles(url) {
+		if p.Hints == "" || a.shownHints[p.Name] {
+			continue
+		}
+		a.shownHints[p.Name] = true
+		b.WriteString(fmt.Sprintf("\n### Site hints (%s):\n%s\n", p.Name, strings.TrimSpace(p.Hints)))
+	}
+	return b.String()
+}
+
+func (a *Agent) executeReadPage(ctx context.Context, args map[string]interface{}) (string, error) {
+	page := 1
+	if p, ok := args["page"].(float64); ok && p >= 1 {
+		page = int(p)
+	}
+
+	a.extractor.UpdatePage(a.browser.GetPage())
+
+	content, err := a.extractor.ReadPage(ctx, page)
+	if err != nil {
+		return fmt.Sprintf("Error reading page: %v", err), nil
+	}
+	return a.extractor.FormatReadableForLLM(content), nil
+}
+
+func (a *Agent) executeExtractTable(ctx context.Context, args map[string]interface{}) (string, error) {
+	index := 0
+	if v, ok := args["table"].(float64); ok {
+		index = int(v)
+	}
+	page := 1
+	if v, ok := args["page"].(float64); ok && v >= 1 {
+		page = int(v)
+	}
+
+	a.extractor.UpdatePage(a.browser.GetPage())
+
+	tables, err := a.extractor.ExtractTables(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error extracting tables: %v", err), nil
+	}
+	return a.extractor.FormatTablesForLLM(tables, index, page), nil
+}
+
+func (a *Agent) executeExtractStructured(ctx context.Context, args map[string]interface{}) (string, error) {
+	outputSchema := a.outputSchema
+	if s, ok := args["schema"].(string); ok && strings.TrimSpace(s) != "" {
+		outputSchema = json.RawMessage(s)
+	}
+	if len(outputSchema) == 0 {
+		return "Error: 'schema' is required: the task has no output schema", nil
+	}
+	if !json.Valid(outputSchema) {
+		return "Error: 'schema' is not valid JSON", nil
+	}
+
+	a.extractor.UpdatePage(a.browser.GetPage())
+
+	sources, err := a.extractor.StructuredSources(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error collecting page data: %v", err), nil
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: llm.StructuredExtractionPrompt,
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("JSON Schema:\n%s\n\nPage sources:\n%s", outputSchema, sources),
+		},
+	}
+
+	// Ответ модели проверяем по схеме и при ошибках переспрашиваем с их списком
+	var problem string
+	attempts := a.schemaAttempts()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		data, err := a.llm.CompleteJSON(ctx, messages)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			problem = err.Error()
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("%s. Return only JSON matching the schema.", problem),
+			})
+			continue
+		}
+
+		errs, err := schema.Validate(outputSchema, data)
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		if len(errs) == 0 {
+			return fmt.Sprintf("Extracted data:\n%s\n\nCheck it and pass it to report as data.", data), nil
+		}
+
+		problem = schema.FormatErrors(errs)
+		a.logger.Debug("Structured data does not match schema", "attempt", attempt, "errors", problem)
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: string(data)},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("The JSON does not match the schema: %s. Return the corrected JSON only.", problem),
+			},
+		)
+	}
+
+	return fmt.Sprintf("Error: could not extract data matching the schema after %d attempts: %s", attempts, problem), nil
+}
+
+// schemaAttempts — сколько раз модель может исправить данные, не подходящие под схему задачи
+func (a *Agent) schemaAttempts() int {
+	if a.config.SchemaAttempts > 0 {
+		return a.config.SchemaAttempts
+	}
+	return defaultSchemaAttempts
+}
+
+func (a *Agent) executeScreenshot(ctx context.Context, args map[string]interface{}) (string, error) {
+	id := -1
+	target := "the visible page"
+	if _, ok := args["element_id"]; ok {
+		var err error
+		if id, err = extractElementID(args); err != nil {
+			return fmt.Sprintf("Error: %v", err), nil
+		}
+		target = fmt.Sprintf("element [%d]", id)
+	}
+
+	data, err := a.browser.Screenshot(ctx, id)
+	if err != nil {
+		return fmt.Sprintf("Error taking screenshot: %v", err), nil
+	}
+
+	saved := ""
+	path, err := a.artifacts.Save(fmt.Sprintf("step-%02d-screenshot.png", a.step), data)
+	if err != nil {
+		a.logger.Warn("Failed to save screenshot", "error", err.Error())
+	} else {
+		saved = fmt.Sprintf(" Saved to %s.", path)
+	}
+
+	if !a.llm.SupportsVision() {
+		return fmt.Sprintf("Screenshot of %s taken.%s The current model cannot view images: use extract_page or read_page instead.", target, saved), nil
+	}
+
+	a.attachImage(data, fmt.Sprintf("Screenshot of %s (step %d):", target, a.step))
+
+	return fmt.Sprintf("Screenshot of %s taken.%s The image is attached to the next message.", target, saved), nil
 }
 
 func (a *Agent) executeNavigate(ctx context.Context, args map[string]interface{}) (string, error) {
@@ -58,10 +342,165 @@ func (a *Agent) executeNavigate(ctx context.Context, args map[string]interface{}
 	a.logger.Navigate(url)
 
 	if err := a.browser.Navigate(ctx, url); err != nil {
+		// Прямая ссылка на файл не открывает страницу, а начинает загрузку
+		if downloads := a.downloadEvents(); downloads != "" {
+			return fmt.Sprintf("%s opened no page.", url) + downloads, nil
+		}
 		return fmt.Sprintf("Error navigating to %s: %v", url, err), nil
 	}
+	// Новый документ — реестр элементов страницы пуст, даже если URL тот же
+	a.extractor.Reset()
+
+	return fmt.Sprintf("Navigated to %s. Call extract_page to see the page content.", url) + a.downloadEvents() + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeGoBack(ctx context.Context, args map[string]interface{}) (string, error) {
+	if err := a.browser.GoBack(ctx); err != nil {
+		return fmt.Sprintf("Error going back: %v", err), nil
+	}
+	a.extractor.Reset()
+
+	return fmt.Sprintf("Went back to %s. Call extract_page to see the page content.", a.browser.GetURL()) + a.tabEvents() + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeGoForward(ctx context.Context, args map[string]interface{}) (string, error) {
+	if err := a.browser.GoForward(ctx); err != nil {
+		return fmt.Sprintf("Error going forward: %v", err), nil
+	}
+	a.extractor.Reset()
+
+	return fmt.Sprintf("Went forward to %s. Call extract_page to see the page content.", a.browser.GetURL()) + a.tabEvents() + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeReload(ctx context.Context, args map[string]interface{}) (string, error) {
+	if err := a.browser.Reload(ctx); err != nil {
+		return fmt.Sprintf("Error reloading page: %v", err), nil
+	}
+	// После перезагрузки реестр элементов страницы пуст — ID выдаются заново
+	a.extractor.Reset()
+
+	return "Reloaded the page. Call extract_page to see the page content." + a.tabEvents() + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeListTabs(ctx context.Context, args map[string]interface{}) (string, error) {
+	tabs, err := a.browser.ListTabs(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error listing tabs: %v", err), nil
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Open tabs (%d):\n", len(tabs)))
+	for _, t := range tabs {
+		b.WriteString(formatTab(t) + "\n")
+	}
+	return b.String(), nil
+}
+
+func (a *Agent) executeSwitchTab(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, ok := args["tab_id"].(float64)
+	if !ok {
+		return "Error: 'tab_id' is required and must be a number", nil
+	}
+
+	if err := a.browser.SwitchTab(ctx, int(id)); err != nil {
+		return fmt.Sprintf("Error switching tab: %v", err), nil
+	}
+
+	// ID элементов у каждой вкладки свои — следующее извлечение должно быть полным
+	a.extractor.Reset()
+
+	return fmt.Sprintf("Switched to tab [%d]. Call extract_page to see it.", int(id)), nil
+}
+
+func (a *Agent) executeOpenTab(ctx context.Context, args map[string]interface{}) (string, error) {
+	url, ok := args["url"].(string)
+	if !ok || url == "" {
+		return "Error: 'url' argument is required and must be a string", nil
+	}
+
+	a.logger.Navigate(url)
+
+	tab, err := a.browser.OpenTab(ctx, url)
+	if err != nil {
+		if tab.ID == 0 {
+			return fmt.Sprintf("Error opening tab: %v", err), nil
+		}
+		// Вкладка открылась и стала активной, не открылся только URL
+		a.extractor.Reset()
+		return fmt.Sprintf("Opened new tab [%d], it is active now, but navigation failed: %v", tab.ID, err), nil
+	}
+	a.extractor.Reset()
+
+	return fmt.Sprintf("Opened %s in new tab [%d], it is active now. Call extract_page to see it.", url, tab.ID) + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeCloseTab(ctx context.Context, args map[string]interface{}) (string, error) {
+	current, _ := a.browser.ActiveTab()
+	id := current.ID
+	if v, ok := args["tab_id"].(float64); ok {
+		id = int(v)
+	}
+
+	active, err := a.browser.CloseTab(ctx, id)
+	if err != nil {
+		return fmt.Sprintf("Error closing tab: %v", err), nil
+	}
+
+	if active.ID != current.ID {
+		a.extractor.Reset()
+		return fmt.Sprintf("Closed tab [%d]. Active tab is now [%d] %s. Call extract_page to see it.", id, active.ID, active.URL), nil
+	}
+	return fmt.Sprintf("Closed tab [%d].", id), nil
+}
+
+// tabEvents описывает вкладки, которые страница открыла или закрыла после действия.
+// Новые вкладки не активируются сами: модель решает, переключаться ли на них.
+func (a *Agent) tabEvents() string {
+	opened, closed := a.browser.TakeTabEvents()
+	if len(opened) == 0 && len(closed) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, t := range opened {
+		b.WriteString(fmt.Sprintf("\nThe page opened a new tab: %s", formatTab(t)))
+		b.WriteString(fmt.Sprintf("\nIt is NOT active. Call switch_tab(%d) to work in it (for login/OAuth popups: switch, sign in, and the popup usually closes itself).", t.ID))
+	}
+	for _, id := range closed {
+		b.WriteString(fmt.Sprintf("\nTab [%d] was closed by the page.", id))
+	}
+	if len(closed) > 0 {
+		active, _ := a.browser.ActiveTab()
+		b.WriteString(fmt.Sprintf(" Active tab: [%d] %s", active.ID, active.URL))
+		a.extractor.Reset()
+	}
+	return b.String()
+}
+
+// settleNote предупреждает модель, если после действия страница не успокоилась за отведённое время
+func (a *Agent) settleNote() string {
+	res, ok := a.browser.TakeSettle()
+	if !ok || res.Settled {
+		return ""
+	}
+	return fmt.Sprintf("\nWarning: the page did not settle within %.1fs (%s). It may still be loading or updating: call wait and then extract_page.",
+		res.Waited.Seconds(), strings.Join(res.Pending, "; "))
+}
 
-	return fmt.Sprintf("Navigated to %s. Call extract_page to see the page content.", url), nil
+func formatTab(t types.TabInfo) string {
+	marker := " "
+	if t.Active {
+		marker = "*"
+	}
+	line := fmt.Sprintf("%s[%d] %q %s", marker, t.ID, t.Title, t.URL)
+	if t.Popup {
+		if t.OpenerID > 0 {
+			line += fmt.Sprintf(" (popup from [%d])", t.OpenerID)
+		} else {
+			line += " (popup)"
+		}
+	}
+	return line
 }
 
 func (a *Agent) executeClick(ctx context.Context, args map[string]interface{}) (string, error) {
@@ -72,11 +511,42 @@ func (a *Agent) executeClick(ctx context.Context, args map[string]interface{}) (
 
 	a.logger.Click(id, "")
 
-	if err := a.browser.ClickByID(ctx, id); err != nil {
+	res, err := a.browser.ClickByID(ctx, id)
+	if err != nil {
 		return fmt.Sprintf("Error clicking element [%d]: %v. Try extract_page to refresh elements.", id, err), nil
 	}
 
-	return fmt.Sprintf("Clicked element [%d]. Call extract_page to see the result.", id), nil
+	return fmt.Sprintf("Clicked element [%d]%s. Call extract_page to see the result.", id, inputNote(res)) + a.tabEvents() + a.downloadEvents() + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeHover(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, err := extractElementID(args)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	res, err := a.browser.HoverByID(ctx, id)
+	if err != nil {
+		return fmt.Sprintf("Error hovering element [%d]: %v. Try extract_page to refresh elements.", id, err), nil
+	}
+
+	return fmt.Sprintf("Mouse is over element [%d]%s. Call extract_page to see menus or tooltips it opened.", id, inputNote(res)) + a.settleNote() + a.dialogNote(), nil
+}
+
+// inputNote поясняет, каким способом выполнено действие с элементом
+func inputNote(res types.InputResult) string {
+	// Способ неизвестен, если действие прервал JS-диалог
+	if res.Method == "" {
+		return ""
+	}
+	if res.Method != types.InputJS {
+		return " with native input"
+	}
+	note := fmt.Sprintf(" via JS fallback (%s)", res.Fallback)
+	if strings.Contains(res.Fallback, "covered") {
+		note += ". If nothing changed, close what covers the element first"
+	}
+	return note
 }
 
 func (a *Agent) executeTypeText(ctx context.Context, args map[string]interface{}) (string, error) {
@@ -96,11 +566,123 @@ func (a *Agent) executeTypeText(ctx context.Context, args map[string]interface{}
 
 	a.logger.Type(id, text)
 
-	if err := a.browser.TypeByID(ctx, id, text); err != nil {
+	res, err := a.browser.TypeByID(ctx, id, text)
+	if err != nil {
 		return fmt.Sprintf("Error typing into element [%d]: %v. Try extract_page to refresh elements.", id, err), nil
 	}
 
-	return fmt.Sprintf("Typed '%s' into element [%d]. Call extract_page to see the result.", text, id), nil
+	return fmt.Sprintf("Typed '%s' into element [%d]%s. Call extract_page to see the result.", text, id, inputNote(res)) + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeSelectOption(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, err := extractElementID(args)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	value, _ := args["value"].(string)
+	label, _ := args["label"].(string)
+	if value == "" && label == "" {
+		return "Error: 'value' or 'label' argument is required", nil
+	}
+
+	option, err := a.browser.SelectOption(ctx, id, value, label)
+	if err != nil {
+		return fmt.Sprintf("Error selecting option: %v", err), nil
+	}
+
+	return fmt.Sprintf("Selected %q (value=%q) in element [%d]. Call extract_page to see the result.", option.Label, option.Value, id) + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeSetChecked(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, err := extractElementID(args)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	checked, ok := args["checked"].(bool)
+	if !ok {
+		return "Error: 'checked' argument is required and must be a boolean", nil
+	}
+
+	state := "unchecked"
+	if checked {
+		state = "checked"
+	}
+
+	res, err := a.browser.SetChecked(ctx, id, checked)
+	if err != nil {
+		return fmt.Sprintf("Error setting element [%d] %s: %v", id, state, err), nil
+	}
+	if note := a.dialogNote(); note != "" {
+		return fmt.Sprintf("Clicked element [%d] to make it %s.", id, state) + note, nil
+	}
+	if res.Method == "" {
+		return fmt.Sprintf("Element [%d] is already %s.", id, state), nil
+	}
+
+	return fmt.Sprintf("Element [%d] is now %s (clicked%s). Call extract_page to see the result.", id, state, inputNote(res)) + a.settleNote(), nil
+}
+
+func (a *Agent) executeUploadFile(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, err := extractElementID(args)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "Error: 'path' argument is required and must be a string", nil
+	}
+
+	file, err := a.browser.UploadFile(ctx, id, path)
+	if err != nil {
+		return fmt.Sprintf("Error uploading file: %v", err), nil
+	}
+
+	return fmt.Sprintf("Attached %s to element [%d]. Call extract_page to see the result.", filepath.Base(file), id) + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeListDownloads(ctx context.Context, args map[string]interface{}) (string, error) {
+	downloads := a.browser.ListDownloads()
+	if len(downloads) == 0 {
+		return "No downloads in this task yet.", nil
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Downloads (%d):\n", len(downloads)))
+	for i, d := range downloads {
+		line := fmt.Sprintf("%d. %s - %s, %s", i+1, d.Name, d.State, formatSize(d.Size))
+		if d.MIME != "" {
+			line += ", " + d.MIME
+		}
+		if d.Path != "" {
+			line += "\n   saved to " + d.Path
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String(), nil
+}
+
+// downloadEvents сообщает о загрузках, которые начались после действия
+func (a *Agent) downloadEvents() string {
+	var b strings.Builder
+	for _, d := range a.browser.TakeDownloadEvents() {
+		b.WriteString(fmt.Sprintf("\nA download started: %s (%s). Call list_downloads to check when it is completed.", d.Name, d.URL))
+	}
+	return b.String()
+}
+
+// formatSize — размер файла в читаемом виде
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
 }
 
 func (a *Agent) executeScroll(ctx context.Context, args map[string]interface{}) (string, error) {
@@ -115,7 +697,7 @@ func (a *Agent) executeScroll(ctx context.Context, args map[string]interface{})
 		return fmt.Sprintf("Error scrolling: %v", err), nil
 	}
 
-	return fmt.Sprintf("Scrolled %s. Call extract_page to see new elements.", direction), nil
+	return fmt.Sprintf("Scrolled %s. Call extract_page to see new elements.", direction) + a.settleNote() + a.dialogNote(), nil
 }
 
 func (a *Agent) executeWait(ctx context.Context, args map[string]interface{}) (string, error) {
@@ -143,7 +725,57 @@ func (a *Agent) executePressKey(ctx context.Context, args map[string]interface{}
 		return fmt.Sprintf("Error pressing key '%s': %v", key, err), nil
 	}
 
-	return fmt.Sprintf("Pressed %s key. Call extract_page to see the result.", key), nil
+	return fmt.Sprintf("Pressed %s key. Call extract_page to see the result.", key) + a.tabEvents() + a.downloadEvents() + a.settleNote() + a.dialogNote(), nil
+}
+
+func (a *Agent) executeHandleDialog(ctx context.Context, args map[string]interface{}) (string, error) {
+	accept, ok := args["accept"].(bool)
+	if !ok {
+		return "Error: 'accept' argument is required and must be a boolean", nil
+	}
+	text, _ := args["text"].(string)
+
+	dialog := a.browser.PendingDialog()
+	if dialog == nil {
+		return "No dialog is open on the active tab.", nil
+	}
+
+	// Подтверждение удаления и других необратимых действий — только с согласия пользователя
+	denied := false
+	if accept && dialog.Destructive && !a.approveDialog(fmt.Sprintf("Accept the page dialog %q", dialog.Message)) {
+		accept = false
+		denied = true
+	}
+
+	if err := a.browser.HandleDialog(ctx, accept, text); err != nil {
+		return fmt.Sprintf("Error handling dialog: %v", err), nil
+	}
+
+	var result string
+	switch {
+	case denied:
+		result = fmt.Sprintf("User DENIED the action, the %s dialog %q was dismissed. Do NOT retry it.", dialog.Type, dialog.Message)
+	case accept:
+		result = fmt.Sprintf("Accepted the %s dialog %q.", dialog.Type, dialog.Message)
+	default:
+		result = fmt.Sprintf("Dismissed the %s dialog %q.", dialog.Type, dialog.Message)
+	}
+
+	return result + " Call extract_page to see the result." + a.tabEvents() + a.downloadEvents() + a.settleNote() + a.dialogNote(), nil
+}
+
+// dialogNote сообщает о JS-диалоге, который открыт на активной вкладке после действия
+func (a *Agent) dialogNote() string {
+	d := a.browser.PendingDialog()
+	if d == nil {
+		return ""
+	}
+
+	note := fmt.Sprintf("\nThe page opened a %s dialog: %q. The page is blocked until you answer it with handle_dialog.", d.Type, d.Message)
+	if d.Destructive {
+		note += " It confirms an irreversible action: accepting it asks the user for confirmation."
+	}
+	return note
 }
 
 func (a *Agent) executeAskUser(ctx context.Context, args map[string]interface{}) (string, error) {
@@ -152,10 +784,17 @@ func (a *Agent) executeAskUser(ctx context.Context, args map[string]interface{})
 		return "Error: 'question' argument is required", nil
 	}
 
+	if !a.interactive() {
+		return "No user is available to answer. Continue without the answer or report that the task cannot be completed.", nil
+	}
+
+	stdinMu.Lock()
+	defer stdinMu.Unlock()
+
 	a.logger.Ask(question)
 
-	fmt.Printf("\n💬 Agent asks: %s\n", question)
-	fmt.Print("Your answer: ")
+	fmt.Fprintf(os.Stderr, "\n💬 Agent asks: %s\n", question)
+	fmt.Fprint(os.Stderr, "Your answer: ")
 
 	// Используем bufio.Scanner для корректного чтения строки с пробелами
 	reader := bufio.NewReader(os.Stdin)
@@ -170,7 +809,7 @@ func (a *Agent) executeAskUser(ctx context.Context, args map[string]interface{})
 		return "User did not provide an answer. Ask again or try a different approach.", nil
 	}
 
-	fmt.Printf("✅ Received: %s\n\n", answer)
+	fmt.Fprintf(os.Stderr, "✅ Received: %s\n\n", answer)
 
 	return fmt.Sprintf("User answered: %s", answer), nil
 }
@@ -181,27 +820,64 @@ func (a *Agent) executeConfirmAction(ctx context.Context, args map[string]interf
 		description = "Perform this action?"
 	}
 
+	confirmed, err := a.confirm(description)
+	if err != nil {
+		return "Error reading confirmation. Action denied.", nil
+	}
+	if confirmed {
+		return "User confirmed. Proceed with the action.", nil
+	}
+	return "User DENIED the action. Do NOT proceed.", nil
+}
+
+// approveDialog решает, можно ли принять диалог, подтверждающий необратимое действие.
+// Спрашивает пользователя, если подтверждения включены в конфигурации; без терминала
+// спросить некого — диалог отклоняется без вопроса.
+func (a *Agent) approveDialog(description string) bool {
+	if !a.config.SecurityEnabled || !a.config.ConfirmationRequired {
+		return true
+	}
+	if !a.interactive() {
+		a.logger.Warn("No user to confirm the action, denied", "action", description)
+		return false
+	}
+	// Ошибка чтения ответа считается отказом
+	confirmed, err := a.confirm(description)
+	return err == nil && confirmed
+}
+
+// interactive сообщает, что вопросы можно задать пользователю. Ответы могут прийти
+// и через перенаправленный stdin, поэтому решает только режим запуска.
+func (a *Agent) interactive() bool {
+	return !a.config.NonInteractive
+}
+
+// confirm спрашивает у пользователя подтверждение опасного действия
+func (a *Agent) confirm(description string) (bool, error) {
+	stdinMu.Lock()
+	defer stdinMu.Unlock()
+
 	a.logger.Confirm(description)
 
-	fmt.Printf("\n🔒 CONFIRMATION REQUIRED\n")
-	fmt.Printf("Action: %s\n", description)
-	fmt.Print("Proceed? (yes/no): ")
+	fmt.Fprintf(os.Stderr, "\n🔒 CONFIRMATION REQUIRED\n")
+	fmt.Fprintf(os.Stderr, "Action: %s\n", description)
+	fmt.Fprint(os.Stderr, "Proceed? (yes/no): ")
 
 	reader := bufio.NewReader(os.Stdin)
 	answer, err := reader.ReadString('\n')
 	if err != nil {
-		return "Error reading confirmation. Action denied.", nil
+		return false, err
 	}
 
 	answer = strings.TrimSpace(strings.ToLower(answer))
 
 	if answer == "yes" || answer == "y" || answer == "да" || answer == "д" {
-		fmt.Println("✅ Confirmed")
-		return "User confirmed. Proceed with the action.", nil
+		fmt.Fprintln(os.Stderr, "✅ Confirmed")
+		return true, nil
 	}
 
-	fmt.Println("❌ Denied")
-	return "User DENIED the action. Do NOT proceed.", nil
+	fmt.Fprintln(os.Stderr, "❌ Denied")
+	return false, nil
 }
 
 func (a *Agent) executeReport(ctx context.Context, args map[string]interface{}) (string, error) {
@@ -215,11 +891,69 @@ func (a *Agent) executeReport(ctx context.Context, args map[string]interface{})
 		success = s
 	}
 
+	data, err := reportData(args)
+	if err != nil {
+		return fmt.Sprintf("Error: %v. Pass data as a JSON string.", err), nil
+	}
+
+	// Успешный результат задачи со схемой проверяем и возвращаем модели ошибки,
+	// пока не закончатся попытки
+	if success && len(a.outputSchema) > 0 {
+		problem := ""
+		if len(data) == 0 {
+			problem = "'data' is required: the task has an output schema"
+		} else {
+			errs, err := schema.Validate(a.outputSchema, data)
+			if err != nil {
+				return "", fmt.Errorf("validate report data: %w", err)
+			}
+			if len(errs) > 0 {
+				problem = "data does not match the output schema: " + schema.FormatErrors(errs)
+			}
+		}
+
+		if problem != "" {
+			a.reportAttempts++
+			if a.reportAttempts < a.schemaAttempts() {
+				return fmt.Sprintf("Report rejected: %s. Fix the data and call report again (attempt %d of %d).",
+					problem, a.reportAttempts, a.schemaAttempts()), nil
+			}
+
+			message = fmt.Sprintf("%s\n(result rejected: %s)", message, problem)
+			success = false
+		}
+	}
+
 	a.logger.Done(message, success)
 
+	a.result = &types.RunResult{
+		Message: message,
+		Success: success,
+		Data:    data,
+	}
+
 	return message, nil
 }
 
+// reportData достаёт data из аргументов report: модель передаёт его и строкой, и объектом
+func reportData(args map[string]interface{}) (json.RawMessage, error) {
+	v, ok := args["data"]
+	if !ok {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var data llm.JSONData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
+
 // extractElementID извлекает ID элемента из аргументов
 func extractElementID(args map[string]interface{}) (int, error) {
 	if v, ok := args["element_id"].(float64); ok {
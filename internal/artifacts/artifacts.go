@@ -0,0 +1,80 @@
+package artifacts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Run — каталог артефактов одного запуска задачи (скриншоты, файлы, логи запросов).
+// Каталог создаётся при первом сохранении, чтобы задачи без артефактов не оставляли пустых папок.
+type Run struct {
+	dir string
+}
+
+// claimed — каталоги, выданные запускам этого процесса. Каталог создаётся лениво,
+// поэтому занятость имени нельзя проверить только по диску.
+var (
+	claimedMu sync.Mutex
+	claimed   = map[string]bool{}
+)
+
+// NewRun возвращает каталог артефактов запуска внутри base, имя — время старта задачи.
+// Если задача на той же секунде уже заняла имя, к нему добавляется номер: -2, -3 и т.д.
+func NewRun(base string, started time.Time) *Run {
+	name := started.Format("20060102-150405")
+
+	claimedMu.Lock()
+	defer claimedMu.Unlock()
+	dir := filepath.Join(base, name)
+	for i := 2; claimed[dir] || exists(dir); i++ {
+		dir = filepath.Join(base, fmt.Sprintf("%s-%d", name, i))
+	}
+	claimed[dir] = true
+	return &Run{dir: dir}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Dir возвращает путь к каталогу запуска
+func (r *Run) Dir() string {
+	return r.dir
+}
+
+// Prune удаляет пустые подкаталоги запуска (например, downloads без загрузок)
+// и сам каталог, если в нём ничего не осталось
+func (r *Run) Prune() {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			// os.Remove не удаляет непустые каталоги
+			_ = os.Remove(filepath.Join(r.dir, e.Name()))
+		}
+	}
+	_ = os.Remove(r.dir)
+}
+
+var unsafeName = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Save записывает файл в каталог запуска и возвращает путь к нему.
+// Недопустимые символы в имени заменяются на "_".
+func (r *Run) Save(name string, data []byte) (string, error) {
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return "", fmt.Errorf("create artifacts dir: %w", err)
+	}
+
+	path := filepath.Join(r.dir, unsafeName.ReplaceAllString(filepath.Base(name), "_"))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write artifact %s: %w", name, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,83 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRun_Save(t *testing.T) {
+	base := t.TempDir()
+	run := NewRun(base, time.Date(2025, 3, 1, 14, 5, 9, 0, time.UTC))
+
+	if want := filepath.Join(base, "20250301-140509"); run.Dir() != want {
+		t.Errorf("got dir %s, want %s", run.Dir(), want)
+	}
+	if _, err := os.Stat(run.Dir()); !os.IsNotExist(err) {
+		t.Error("dir should not be created before the first artifact")
+	}
+
+	path, err := run.Save("../step 3: screenshot.png", []byte("png"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(run.Dir(), "step_3_screenshot.png"); path != want {
+		t.Errorf("got path %s, want %s", path, want)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "png" {
+		t.Errorf("unexpected file content %q, %v", data, err)
+	}
+}
+
+func TestRun_Prune(t *testing.T) {
+	base := t.TempDir()
+
+	empty := NewRun(base, time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC))
+	if err := os.MkdirAll(filepath.Join(empty.Dir(), "downloads"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	empty.Prune()
+	if _, err := os.Stat(empty.Dir()); !os.IsNotExist(err) {
+		t.Error("run without artifacts should be removed")
+	}
+
+	used := NewRun(base, time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC))
+	if err := os.MkdirAll(filepath.Join(used.Dir(), "downloads"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := used.Save("shot.png", []byte("png")); err != nil {
+		t.Fatal(err)
+	}
+	used.Prune()
+	if _, err := os.Stat(filepath.Join(used.Dir(), "shot.png")); err != nil {
+		t.Errorf("artifacts must be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(used.Dir(), "downloads")); !os.IsNotExist(err) {
+		t.Error("empty downloads dir should be removed")
+	}
+}
+
+func TestNewRun_SameSecond(t *testing.T) {
+	base := t.TempDir()
+	started := time.Date(2025, 3, 1, 14, 5, 9, 0, time.UTC)
+
+	first := NewRun(base, started)
+	second := NewRun(base, started)
+	if first.Dir() == second.Dir() {
+		t.Fatalf("runs started in the same second share dir %s", first.Dir())
+	}
+	if want := filepath.Join(base, "20250301-140509-2"); second.Dir() != want {
+		t.Errorf("got dir %s, want %s", second.Dir(), want)
+	}
+
+	// Каталог, оставшийся от другого процесса, тоже не переиспользуется
+	if err := os.MkdirAll(filepath.Join(base, "20250301-140510"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := NewRun(base, started.Add(time.Second)).Dir(); got != filepath.Join(base, "20250301-140510-2") {
+		t.Errorf("existing dir reused: %s", got)
+	}
+}
@@ -0,0 +1,107 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// attach подключается к уже запущенному Chrome по RemoteURL и берёт под управление его вкладки.
+// Каталог данных не блокируется: им владеет чужой процесс Chrome.
+func (m *Manager) attach(ctx context.Context) (err error) {
+	u, err := resolveRemoteURL(m.config.RemoteURL)
+	if err != nil {
+		return fmt.Errorf("resolve DevTools URL %s: %w", m.config.RemoteURL, err)
+	}
+
+	// Соединение живёт в своём контексте: его отмена закрывает websocket, не трогая браузер.
+	// Экран rod по умолчанию вкладкам пользователя не навязываем.
+	connCtx, disconnect := context.WithCancel(context.Background())
+	b := rod.New().Context(connCtx).ControlURL(u).NoDefaultDevice()
+	if err := b.Connect(); err != nil {
+		disconnect()
+		return fmt.Errorf("connect to browser %s: %w", m.config.RemoteURL, err)
+	}
+	m.browser = b
+	m.disconnect = disconnect
+
+	// При ошибке снимаем перехват с чужого браузера и закрываем соединение
+	defer func() {
+		if err != nil {
+			_ = m.Close()
+			m.disconnect = nil
+		}
+	}()
+
+	if m.config.Debug {
+		m.log.Debug("Attached to running browser", "url", u)
+	}
+
+	go m.watchDownloads()
+	go m.watchDialogs()
+	m.startHAR()
+
+	if err := m.startInterception("", ""); err != nil {
+		return err
+	}
+
+	if err := m.adoptTabs(); err != nil {
+		return err
+	}
+
+	m.tabs.mu.Lock()
+	if len(m.tabs.tabs) > 0 {
+		m.page = m.tabs.tabs[0].page
+	}
+	m.tabs.mu.Unlock()
+
+	if m.activePage() == nil {
+		page, err := m.browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+		if err != nil {
+			return fmt.Errorf("open tab: %w", err)
+		}
+		m.tabs.mu.Lock()
+		m.page = page
+		m.tabs.add(page, 0, false)
+		m.tabs.mu.Unlock()
+		m.setupTab(page)
+	}
+
+	return m.SetEmulation(m.config.Emulation)
+}
+
+// Attached сообщает, что агент управляет чужим браузером и не закроет его при выходе
+func (m *Manager) Attached() bool {
+	return m.disconnect != nil
+}
+
+// adoptTabs регистрирует уже открытые вкладки браузера как обычные, а не открытые страницей
+func (m *Manager) adoptTabs() error {
+	if err := m.syncTabs(); err != nil {
+		return err
+	}
+
+	m.tabs.mu.Lock()
+	defer m.tabs.mu.Unlock()
+	for _, t := range m.tabs.tabs {
+		t.popup = false
+	}
+	m.tabs.opened = nil
+	m.tabs.closed = nil
+	return nil
+}
+
+// resolveRemoteURL превращает адрес отладки в WebSocket URL браузера. Принимает
+// ws://host:port/devtools/browser/<id> как есть, а http://host:port, host:port и номер
+// порта — через /json/version.
+func resolveRemoteURL(remote string) (string, error) {
+	remote = strings.TrimSpace(remote)
+	if strings.HasPrefix(remote, "ws://") || strings.HasPrefix(remote, "wss://") {
+		return remote, nil
+	}
+	return launcher.ResolveURL(remote)
+}
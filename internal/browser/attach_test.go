@@ -0,0 +1,37 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveRemoteURL(t *testing.T) {
+	ws := "ws://10.0.0.5:9222/devtools/browser/3f2a"
+	if got, err := resolveRemoteURL(" " + ws + " "); err != nil || got != ws {
+		t.Errorf("WebSocket URL should be used as is, got %q, %v", got, err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/version" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"webSocketDebuggerUrl": "ws://127.0.0.1:9222/devtools/browser/3f2a"}`))
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	want := "ws://" + host + "/devtools/browser/3f2a"
+	// Хост из ответа Chrome заменяется адресом, по которому он доступен (контейнер, проброс порта)
+	for _, remote := range []string{srv.URL, host} {
+		got, err := resolveRemoteURL(remote)
+		if err != nil {
+			t.Fatalf("resolveRemoteURL(%q): %v", remote, err)
+		}
+		if got != want {
+			t.Errorf("resolveRemoteURL(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
@@ -3,7 +3,7 @@ package browser
 import (
 	"context"
 	"fmt"
-	"time"
+	"sync"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/input"
@@ -11,6 +11,7 @@ import (
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/stannisl/ai-browser-assistant/internal/logger"
 	"github.com/stannisl/ai-browser-assistant/internal/types"
+	"github.com/stannisl/ai-browser-assistant/internal/userdata"
 )
 
 var keyMapping = map[string]input.Key{
@@ -31,6 +32,29 @@ type Manager struct {
 	page    *rod.Page
 	config  *types.BrowserConfig
 	log     *logger.Logger
+	tabs    tabRegistry
+	// proc — процесс Chrome, запущенный менеджером
+	proc *launcher.Launcher
+	// lock — блокировка каталога данных от второго запуска на том же профиле
+	lock *userdata.Lock
+	// disconnect закрывает соединение с чужим браузером (режим RemoteURL)
+	disconnect context.CancelFunc
+	// stopEvents отменяет подписки на события инкогнито-контекста (NewIncognito)
+	stopEvents context.CancelFunc
+
+	// intercept останавливает перехват запросов: правила config.Network и авторизацию прокси
+	intercept context.CancelFunc
+	network   *networkRules
+	// har записывает трафик вкладок по config.HAR
+	har *harRecorder
+	// emulation — действующая эмуляция устройства и региона, применяется и к новым вкладкам
+	emulation *emulation
+
+	downloads downloadRegistry
+	dialogs   dialogRegistry
+
+	settleMu   sync.Mutex
+	lastSettle *types.SettleResult
 }
 
 func NewManager(config *types.BrowserConfig, log *logger.Logger) *Manager {
@@ -40,16 +64,51 @@ func NewManager(config *types.BrowserConfig, log *logger.Logger) *Manager {
 	}
 }
 
-func (m *Manager) Launch(ctx context.Context) error {
-	l, err := launcher.New().
+// Launch запускает свой Chrome или, если задан RemoteURL, подключается к уже запущенному
+func (m *Manager) Launch(ctx context.Context) (err error) {
+	if m.config.RemoteURL != "" {
+		return m.attach(ctx)
+	}
+
+	if m.config.UserDataDir != "" {
+		lock, err := userdata.Acquire(m.config.UserDataDir)
+		if err != nil {
+			return err
+		}
+		m.lock = lock
+	}
+
+	var proxyServer, proxyUser, proxyPassword string
+	if m.config.Proxy != "" {
+		proxyServer, proxyUser, proxyPassword, err = ParseProxy(m.config.Proxy)
+		if err != nil {
+			m.releaseLock()
+			return err
+		}
+	}
+
+	proc := launcher.New().
 		Headless(m.config.Headless).
-		UserDataDir(m.config.UserDataDir).Launch()
+		UserDataDir(m.config.UserDataDir)
+	if proxyServer != "" {
+		proc = proc.Proxy(proxyServer)
+	}
+	l, err := proc.Launch()
 	if err != nil {
+		m.releaseLock()
 		return fmt.Errorf("creating launcher failed: %w", err)
 	}
+	m.proc = proc
+
+	// Дальше браузер уже запущен: при ошибке закрываем его и снимаем блокировку профиля
+	defer func() {
+		if err != nil {
+			_ = m.Close()
+		}
+	}()
 
 	if m.config.Debug {
-		m.log.Debug("Browser launched", "headless", m.config.Headless, "userDataDir", m.config.UserDataDir)
+		m.log.Debug("Browser launched", "headless", m.config.Headless, "userDataDir", m.config.UserDataDir, "proxy", proxyServer)
 	}
 
 	m.browser = rod.New().
@@ -60,7 +119,28 @@ func (m *Manager) Launch(ctx context.Context) error {
 		m.log.Debug("Rod browser instance created")
 	}
 
-	m.page = m.browser.MustPage("about:blank")
+	go m.watchDownloads()
+	go m.watchDialogs()
+	m.startHAR()
+
+	if err := m.startInterception(proxyUser, proxyPassword); err != nil {
+		return err
+	}
+
+	page := m.browser.MustPage("about:blank")
+	m.tabs.mu.Lock()
+	m.page = page
+	m.tabs.add(page, 0, false)
+	m.tabs.mu.Unlock()
+	m.setupTab(page)
+
+	// Вкладки, восстановленные браузером при запуске, регистрируем как обычные, а не открытые страницей
+	if err := m.adoptTabs(); err != nil {
+		return err
+	}
+	if err := m.SetEmulation(m.config.Emulation); err != nil {
+		return err
+	}
 
 	if m.config.Debug {
 		m.log.Debug("Browser page initialized")
@@ -82,13 +162,17 @@ func (m *Manager) Navigate(ctx context.Context, url string) error {
 		m.log.Debug("Navigating to URL", "url", url)
 	}
 
-	err := m.page.Navigate(url)
+	w := m.watchSettle()
+	// Уход со страницы с несохранёнными данными может открыть диалог beforeunload
+	_, err := untilDialog(w, func(context.Context) (struct{}, error) {
+		return struct{}{}, m.activePage().Navigate(url)
+	})
 	if err != nil {
+		w.cancel()
 		return fmt.Errorf("navigation to %s failed: %w", url, err)
 	}
 
-	_ = m.page.WaitLoad()
-	time.Sleep(1 * time.Second)
+	m.settle(ctx, w)
 
 	if m.config.Debug {
 		m.log.Debug("Page loaded successfully", "url", url)
@@ -97,146 +181,115 @@ func (m *Manager) Navigate(ctx context.Context, url string) error {
 	return nil
 }
 
-func (m *Manager) ClickByID(ctx context.Context, id int) error {
+// ClickByID кликает элемент из extract_page. По умолчанию — настоящей мышью в центр элемента,
+// через JS — только если элемент скрыт, перекрыт или мышь недоступна.
+func (m *Manager) ClickByID(ctx context.Context, id int) (types.InputResult, error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return types.InputResult{}, ctx.Err()
 	default:
 	}
 
 	if id < 0 {
-		return fmt.Errorf("invalid element ID: %d", id)
+		return types.InputResult{}, fmt.Errorf("invalid element ID: %d", id)
 	}
 
 	if m.config.Debug {
 		m.log.Debug("Clicking element by ID", "id", id)
 	}
 
-	// Получаем количество страниц до клика
-	pagesBefore := len(m.browser.MustPages())
-
-	// Клик через JS, который предотвращает открытие новых вкладок
-	_, err := m.page.Eval(`(id) => {
-		if (!window._ai_elements) {
-			throw new Error("No elements extracted. Call extract_page first.");
-		}
-		
-		const el = window._ai_elements[id];
-		if (!el) {
-			throw new Error("Element ID " + id + " not found. Total elements: " + window._ai_elements.length);
-		}
-		
-		if (!document.contains(el)) {
-			throw new Error("Element ID " + id + " is no longer in the DOM. Call extract_page again.");
-		}
-		
-		// Скроллим к элементу
-		el.scrollIntoView({block: "center", inline: "center"});
-		
-		// Удаляем target="_blank" чтобы не открывать новую вкладку
-		if (el.tagName.toLowerCase() === 'a') {
-			el.removeAttribute('target');
-			// Также удаляем rel="noopener" который может мешать
-			el.removeAttribute('rel');
-		}
-		
-		// Небольшая пауза после скролла
-		return new Promise(resolve => {
-			setTimeout(() => {
-				el.click();
-				resolve(true);
-			}, 100);
-		});
-	}`, id)
+	w := m.watchSettle()
 
+	// Ссылки с target=_blank открывают новую вкладку — её подхватит реестр вкладок.
+	// Если клик открыл confirm или alert, возвращаемся сразу: страница ждёт ответа.
+	res, err := untilDialog(w, func(ctx context.Context) (types.InputResult, error) {
+		return m.clickElement(ctx, id)
+	})
 	if err != nil {
-		return fmt.Errorf("click element [%d]: %w", id, err)
+		w.cancel()
+		return types.InputResult{}, fmt.Errorf("click element [%d]: %w", id, err)
 	}
 
 	// Ждём реакции страницы
-	time.Sleep(300 * time.Millisecond)
-
-	// Проверяем, не открылась ли новая вкладка
-	pagesAfter := m.browser.MustPages()
-	if len(pagesAfter) > pagesBefore {
-		// Переключаемся на новую вкладку
-		m.page = pagesAfter[len(pagesAfter)-1]
-
-		// Закрываем старые вкладки (кроме текущей)
-		for i, p := range pagesAfter {
-			if i < len(pagesAfter)-1 {
-				p.Close()
-			}
-		}
+	m.settle(ctx, w)
 
-		// Ждём загрузки новой страницы
-		_ = m.page.WaitLoad()
-		time.Sleep(500 * time.Millisecond)
+	if m.config.Debug {
+		m.log.Debug("Element clicked successfully", "id", id, "method", string(res.Method), "fallback", res.Fallback)
+	}
 
-		if m.config.Debug {
-			m.log.Debug("Switched to new tab", "id", id)
-		}
+	return res, nil
+}
+
+// HoverByID наводит мышь на элемент из extract_page (выпадающие меню, подсказки)
+func (m *Manager) HoverByID(ctx context.Context, id int) (types.InputResult, error) {
+	select {
+	case <-ctx.Done():
+		return types.InputResult{}, ctx.Err()
+	default:
+	}
+
+	if id < 0 {
+		return types.InputResult{}, fmt.Errorf("invalid element ID: %d", id)
 	}
 
 	if m.config.Debug {
-		m.log.Debug("Element clicked successfully", "id", id)
+		m.log.Debug("Hovering element by ID", "id", id)
 	}
 
-	return nil
+	w := m.watchSettle()
+
+	res, err := untilDialog(w, func(ctx context.Context) (types.InputResult, error) {
+		return m.hoverElement(ctx, id)
+	})
+	if err != nil {
+		w.cancel()
+		return types.InputResult{}, fmt.Errorf("hover element [%d]: %w", id, err)
+	}
+
+	m.settle(ctx, w)
+
+	return res, nil
 }
 
-func (m *Manager) TypeByID(ctx context.Context, id int, text string) error {
+// TypeByID вводит текст в поле из extract_page, заменяя его содержимое. По умолчанию — кликом
+// и вставкой текста через CDP, через JS — если поле перекрыто или не приняло текст.
+func (m *Manager) TypeByID(ctx context.Context, id int, text string) (types.InputResult, error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return types.InputResult{}, ctx.Err()
 	default:
 	}
 
 	if id < 0 {
-		return fmt.Errorf("invalid element ID: %d", id)
+		return types.InputResult{}, fmt.Errorf("invalid element ID: %d", id)
 	}
 
 	if text == "" {
-		return fmt.Errorf("text cannot be empty")
+		return types.InputResult{}, fmt.Errorf("text cannot be empty")
 	}
 
 	if m.config.Debug {
 		m.log.Debug("Typing into element by ID", "id", id, "text", text)
 	}
 
-	_, err := m.page.Eval(`(args) => {
-		if (!window._ai_elements) {
-			throw new Error("No elements extracted. Call extract_page first.");
-		}
-		
-		const el = window._ai_elements[args.id];
-		if (!el) {
-			throw new Error("Element ID " + args.id + " not found. Total elements: " + window._ai_elements.length);
-		}
-		
-		if (!document.contains(el)) {
-			throw new Error("Element ID " + args.id + " is no longer in the DOM. Call extract_page again.");
-		}
-		
-		el.scrollIntoView({block: "center"});
-		el.focus();
-		el.value = '';
-		el.value = args.text;
-		el.dispatchEvent(new Event('input', { bubbles: true }));
-		el.dispatchEvent(new Event('change', { bubbles: true }));
-		el.dispatchEvent(new KeyboardEvent('keyup', { bubbles: true }));
-		return true;
-	}`, map[string]interface{}{"id": id, "text": text})
+	w := m.watchSettle()
 
+	res, err := untilDialog(w, func(ctx context.Context) (types.InputResult, error) {
+		return m.typeElement(ctx, id, text)
+	})
 	if err != nil {
-		return fmt.Errorf("type into element [%d]: %w", id, err)
+		w.cancel()
+		return types.InputResult{}, fmt.Errorf("type into element [%d]: %w", id, err)
 	}
 
+	// Ввод может запустить подсказки или поиск на лету
+	m.settle(ctx, w)
+
 	if m.config.Debug {
-		m.log.Debug("Text entered successfully", "id", id)
+		m.log.Debug("Text entered successfully", "id", id, "method", string(res.Method), "fallback", res.Fallback)
 	}
 
-	return nil
+	return res, nil
 }
 
 func (m *Manager) Click(ctx context.Context, selector string) error {
@@ -250,7 +303,7 @@ func (m *Manager) Click(ctx context.Context, selector string) error {
 		m.log.Debug("Clicking element by selector", "selector", selector)
 	}
 
-	el, err := m.page.Element(selector)
+	el, err := m.activePage().Element(selector)
 	if err != nil {
 		return fmt.Errorf("element %s not found: %w", selector, err)
 	}
@@ -278,7 +331,7 @@ func (m *Manager) Type(ctx context.Context, selector, text string) error {
 		m.log.Debug("Typing into element", "selector", selector, "text", text)
 	}
 
-	el, err := m.page.Element(selector)
+	el, err := m.activePage().Element(selector)
 	if err != nil {
 		return fmt.Errorf("element %s not found: %w", selector, err)
 	}
@@ -315,12 +368,17 @@ func (m *Manager) Scroll(ctx context.Context, direction string) error {
 		return fmt.Errorf("invalid scroll direction: %s (use 'up' or 'down')", direction)
 	}
 
-	_, err := m.page.Eval(scrollScript)
+	w := m.watchSettle()
+	_, err := untilDialog(w, func(context.Context) (*proto.RuntimeRemoteObject, error) {
+		return m.activePage().Eval(scrollScript)
+	})
 	if err != nil {
+		w.cancel()
 		return fmt.Errorf("scroll failed: %w", err)
 	}
 
-	time.Sleep(300 * time.Millisecond)
+	// Прокрутка может подгрузить ленивый контент
+	m.settle(ctx, w)
 
 	if m.config.Debug {
 		m.log.Debug("Page scrolled successfully", "direction", direction)
@@ -349,12 +407,16 @@ func (m *Manager) PressKey(ctx context.Context, key string) error {
 		m.log.Debug("Pressing keyboard key", "key", key)
 	}
 
-	err := m.page.Keyboard.Press(inputKey)
+	w := m.watchSettle()
+	_, err := untilDialog(w, func(context.Context) (struct{}, error) {
+		return struct{}{}, m.activePage().Keyboard.Press(inputKey)
+	})
 	if err != nil {
+		w.cancel()
 		return fmt.Errorf("press key %s failed: %w", key, err)
 	}
 
-	time.Sleep(100 * time.Millisecond)
+	m.settle(ctx, w)
 
 	if m.config.Debug {
 		m.log.Debug("Key pressed successfully", "key", key)
@@ -364,11 +426,11 @@ func (m *Manager) PressKey(ctx context.Context, key string) error {
 }
 
 func (m *Manager) GetPage() *rod.Page {
-	return m.page
+	return m.activePage()
 }
 
 func (m *Manager) GetURL() string {
-	info, err := m.page.Info()
+	info, err := m.activePage().Info()
 	if err != nil {
 		return ""
 	}
@@ -376,20 +438,42 @@ func (m *Manager) GetURL() string {
 }
 
 func (m *Manager) GetTitle() string {
-	info, err := m.page.Info()
+	info, err := m.activePage().Info()
 	if err != nil {
 		return ""
 	}
 	return info.Title
 }
 
+// Close закрывает запущенный браузер. Браузер, к которому агент подключился, остаётся
+// работать: закрывается только соединение.
 func (m *Manager) Close() error {
+	m.stopInterception()
+	if m.disconnect != nil {
+		m.resetDownloadBehavior()
+		m.disconnect()
+		return nil
+	}
 	if m.browser != nil {
-		m.browser.Close()
+		// Зависший или упавший браузер не отвечает на закрытие — завершаем процесс
+		if err := m.browser.Close(); err != nil && m.proc != nil {
+			m.proc.Kill()
+		}
+	}
+	if m.stopEvents != nil {
+		m.stopEvents()
 	}
+	m.releaseLock()
 	return nil
 }
 
+func (m *Manager) releaseLock() {
+	if err := m.lock.Release(); err != nil {
+		m.log.Warn("Failed to release user data lock", "error", err)
+	}
+	m.lock = nil
+}
+
 func normalizeURL(url string) string {
 	if len(url) == 0 {
 		return url
@@ -0,0 +1,181 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// destructiveDialogWords — признаки диалога, подтверждающего необратимое действие
+var destructiveDialogWords = []string{
+	"delete", "remove", "erase", "destroy", "discard", "wipe",
+	"permanent", "irreversible", "cannot be undone", "can't be undone",
+	"удал", "стере", "уничтож", "безвозврат", "необратим", "нельзя отменить", "нельзя будет восстановить",
+}
+
+// dialogRegistry — открытые JS-диалоги по сессиям вкладок и их cross-origin iframe.
+// Пока диалог открыт, страница не выполняет скрипты и не обрабатывает ввод.
+type dialogRegistry struct {
+	mu      sync.Mutex
+	pending map[proto.TargetSessionID]*types.DialogInfo
+}
+
+func (r *dialogRegistry) open(session proto.TargetSessionID, e *proto.PageJavascriptDialogOpening) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pending == nil {
+		r.pending = map[proto.TargetSessionID]*types.DialogInfo{}
+	}
+	r.pending[session] = &types.DialogInfo{
+		Type:          string(e.Type),
+		Message:       e.Message,
+		DefaultPrompt: e.DefaultPrompt,
+		URL:           e.URL,
+		Destructive:   isDestructiveDialog(string(e.Type), e.Message),
+	}
+}
+
+// close убирает диалог сессии; для вкладки — и диалоги её фреймов
+func (r *dialogRegistry) close(session proto.TargetSessionID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for s := range r.pending {
+		if s == session || tabSession(s) == session {
+			delete(r.pending, s)
+		}
+	}
+}
+
+func (r *dialogRegistry) get(tab proto.TargetSessionID) *types.DialogInfo {
+	_, d := r.find(tab)
+	return d
+}
+
+// find возвращает открытый диалог вкладки или одного из её фреймов и сессию,
+// в которой на него нужно ответить
+func (r *dialogRegistry) find(tab proto.TargetSessionID) (proto.TargetSessionID, *types.DialogInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d, ok := r.pending[tab]; ok {
+		info := *d
+		return tab, &info
+	}
+	for s, d := range r.pending {
+		if tabSession(s) == tab {
+			info := *d
+			return s, &info
+		}
+	}
+	return "", nil
+}
+
+// watchDialogs следит за JS-диалогами всех вкладок до закрытия браузера
+func (m *Manager) watchDialogs() {
+	m.browser.EachEvent(func(e *proto.PageJavascriptDialogOpening, session proto.TargetSessionID) {
+		m.dialogs.open(session, e)
+		if m.config.Debug {
+			m.log.Debug("JS dialog opened", "type", string(e.Type), "message", e.Message)
+		}
+	}, func(e *proto.PageJavascriptDialogClosed, session proto.TargetSessionID) {
+		m.dialogs.close(session)
+	}, func(e *proto.TargetDetachedFromTarget) {
+		m.dialogs.close(e.SessionID)
+		frameTabs.Delete(e.SessionID)
+	})()
+}
+
+// PendingDialog возвращает открытый диалог активной вкладки (в том числе из её iframe) или nil
+func (m *Manager) PendingDialog() *types.DialogInfo {
+	return m.dialogs.get(m.activePage().SessionID)
+}
+
+// HandleDialog отвечает на диалог активной вкладки: accept — OK, иначе Cancel.
+// text — ответ для prompt. Действие, которое открыло диалог, после ответа продолжается,
+// поэтому ждём, пока страница успокоится.
+func (m *Manager) HandleDialog(ctx context.Context, accept bool, text string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	page := m.activePage()
+	session, d := m.dialogs.find(page.SessionID)
+	if d == nil {
+		return fmt.Errorf("no dialog is open on the active tab")
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Handling JS dialog", "type", d.Type, "accept", accept)
+	}
+
+	// Диалог iframe отвечается в сессии этого фрейма
+	target := page
+	if session != page.SessionID {
+		target = m.browser.PageFromSession(session)
+	}
+
+	w := m.watchSettle()
+	err := proto.PageHandleJavaScriptDialog{Accept: accept, PromptText: text}.Call(target)
+	if err != nil {
+		w.cancel()
+		return fmt.Errorf("handle %s dialog: %w", d.Type, err)
+	}
+	m.dialogs.close(session)
+
+	m.settle(ctx, w)
+	return nil
+}
+
+// untilDialog выполняет действие, но возвращается сразу, если на вкладке открылся JS-диалог:
+// действие заблокировано до ответа на диалог. В этом случае возвращается нулевой результат
+// без ошибки, а контекст действия отменяется. Шаг, который открыл диалог (переход, нажатие
+// мыши или клавиши, вызов JS), уже отправлен и завершится после HandleDialog — это и есть
+// продолжение, которого ждёт страница. Многошаговые действия (клик с запасным JS, ввод текста)
+// проверяют контекст между шагами, чтобы не продолжать ввод после ответа на диалог.
+func untilDialog[T any](w *settleWatcher, action func(ctx context.Context) (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan result, 1)
+	go func() {
+		defer cancel()
+		value, err := action(ctx)
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-w.dialog:
+		cancel()
+		var zero T
+		return zero, nil
+	}
+}
+
+// isDestructiveDialog определяет, подтверждает ли диалог необратимое действие.
+// alert ничего не подтверждает, beforeunload только уводит со страницы.
+func isDestructiveDialog(kind, message string) bool {
+	if kind != string(proto.PageDialogTypeConfirm) && kind != string(proto.PageDialogTypePrompt) {
+		return false
+	}
+
+	message = strings.ToLower(message)
+	for _, word := range destructiveDialogWords {
+		if strings.Contains(message, word) {
+			return true
+		}
+	}
+	return false
+}
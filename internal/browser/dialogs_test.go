@@ -0,0 +1,121 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestIsDestructiveDialog(t *testing.T) {
+	tests := []struct {
+		kind    string
+		message string
+		want    bool
+	}{
+		{"confirm", "Delete permanently?", true},
+		{"confirm", "Remove 3 items from the cart?", true},
+		{"confirm", "This action cannot be undone. Continue?", true},
+		{"prompt", "Type DELETE to confirm", true},
+		{"confirm", "Удалить письмо безвозвратно?", true},
+		{"confirm", "Save changes before leaving?", false},
+		{"confirm", "Отправить форму?", false},
+		{"alert", "The file was deleted", false},
+		{"beforeunload", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := isDestructiveDialog(tt.kind, tt.message); got != tt.want {
+			t.Errorf("isDestructiveDialog(%q, %q) = %v, want %v", tt.kind, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestDialogRegistry(t *testing.T) {
+	var r dialogRegistry
+
+	if r.get("s1") != nil {
+		t.Fatal("empty registry should have no dialogs")
+	}
+
+	r.open("s1", &proto.PageJavascriptDialogOpening{
+		Type:          proto.PageDialogTypePrompt,
+		Message:       "Your name?",
+		DefaultPrompt: "guest",
+		URL:           "https://example.com",
+	})
+
+	d := r.get("s1")
+	if d == nil || d.Type != "prompt" || d.Message != "Your name?" || d.DefaultPrompt != "guest" || d.Destructive {
+		t.Fatalf("unexpected dialog: %+v", d)
+	}
+	if r.get("s2") != nil {
+		t.Error("dialog of another tab should not be returned")
+	}
+
+	r.close("s1")
+	if r.get("s1") != nil {
+		t.Error("closed dialog should be removed")
+	}
+
+	// Диалог cross-origin iframe относится к вкладке фрейма и отвечается в сессии фрейма
+	frameTabs.Store(proto.TargetSessionID("frame"), proto.TargetSessionID("s1"))
+	defer frameTabs.Delete(proto.TargetSessionID("frame"))
+
+	r.open("frame", &proto.PageJavascriptDialogOpening{Type: proto.PageDialogTypeAlert, Message: "From iframe"})
+	session, d := r.find("s1")
+	if d == nil || d.Message != "From iframe" || session != "frame" {
+		t.Fatalf("iframe dialog: got %q, %+v", session, d)
+	}
+
+	r.close("s1")
+	if r.get("s1") != nil {
+		t.Error("closing a tab should remove dialogs of its frames")
+	}
+}
+
+func TestUntilDialog(t *testing.T) {
+	w := &settleWatcher{dialog: make(chan struct{})}
+
+	v, err := untilDialog(w, func(context.Context) (int, error) { return 7, nil })
+	if v != 7 || err != nil {
+		t.Errorf("finished action: got %d, %v", v, err)
+	}
+
+	failure := errors.New("boom")
+	if _, err := untilDialog(w, func(context.Context) (int, error) { return 0, failure }); !errors.Is(err, failure) {
+		t.Errorf("expected action error, got %v", err)
+	}
+
+	// Действие заблокировано диалогом — возвращаемся, не дожидаясь его
+	blocked := make(chan struct{})
+	steps := make(chan context.Context, 1)
+	w.dialogOnce.Do(func() { close(w.dialog) })
+
+	done := make(chan struct{})
+	go func() {
+		v, err = untilDialog(w, func(ctx context.Context) (int, error) {
+			<-blocked
+			steps <- ctx
+			return 1, nil
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		if v != 0 || err != nil {
+			t.Errorf("interrupted action: got %d, %v", v, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("untilDialog should return when a dialog opens")
+	}
+
+	// Следующие шаги действия видят отмену контекста
+	close(blocked)
+	if ctx := <-steps; ctx.Err() == nil {
+		t.Error("action context should be canceled when a dialog opens")
+	}
+}
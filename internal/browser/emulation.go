@@ -0,0 +1,290 @@
+package browser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/devices"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// defaultGeoAccuracy — точность координат, если она не задана, в метрах
+const defaultGeoAccuracy = 100
+
+// baseDevice — устройство, которое rod эмулирует во всех вкладках запущенного браузера;
+// к нему возвращаются вкладки при сбросе эмуляции
+var baseDevice = devices.LaptopWithMDPIScreen.Landscape()
+
+// devicePresets — устройства, доступные в Emulation.Device
+var devicePresets = map[string]devices.Device{
+	"iphone-se":     devices.IPhone5orSE,
+	"iphone-8":      devices.IPhone6or7or8,
+	"iphone-8-plus": devices.IPhone6or7or8Plus,
+	"iphone-x":      devices.IPhoneX,
+	"ipad":          devices.IPad,
+	"ipad-mini":     devices.IPadMini,
+	"ipad-pro":      devices.IPadPro,
+	"pixel-2":       devices.Pixel2,
+	"pixel-2-xl":    devices.Pixel2XL,
+	"galaxy-s5":     devices.GalaxyS5,
+	"galaxy-fold":   devices.GalaxyFold,
+	"moto-g4":       devices.MotoG4,
+	"nexus-7":       devices.Nexus7,
+	"surface-duo":   devices.SurfaceDuo,
+	"laptop":        devices.LaptopWithMDPIScreen,
+	"laptop-hidpi":  devices.LaptopWithHiDPIScreen,
+	"laptop-touch":  devices.LaptopWithTouch,
+}
+
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// DeviceNames возвращает имена пресетов устройств по алфавиту
+func DeviceNames() []string {
+	names := make([]string, 0, len(devicePresets))
+	for name := range devicePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseGeolocation разбирает координаты вида "55.7558,37.6173" или "55.7558,37.6173,50" (точность в метрах)
+func ParseGeolocation(s string) (*types.Geolocation, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("geolocation must be \"latitude,longitude[,accuracy]\": %q", s)
+	}
+
+	values := make([]float64, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid geolocation %q: %w", s, err)
+		}
+		values[i] = v
+	}
+
+	geo := &types.Geolocation{Latitude: values[0], Longitude: values[1]}
+	if len(values) == 3 {
+		geo.Accuracy = values[2]
+	}
+	return geo, nil
+}
+
+// ValidateEmulation проверяет настройки эмуляции до запуска браузера
+func ValidateEmulation(e *types.Emulation) error {
+	_, err := compileEmulation(e)
+	return err
+}
+
+// emulation — настройки эмуляции, готовые к применению во вкладках
+type emulation struct {
+	device      devices.Device
+	locale      string
+	timezone    string
+	geo         *types.Geolocation
+	colorScheme string
+}
+
+func compileEmulation(e *types.Emulation) (*emulation, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	res := &emulation{device: baseDevice, timezone: e.Timezone, geo: e.Geolocation}
+
+	if e.Device != "" {
+		name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(e.Device)), " ", "-")
+		d, ok := devicePresets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown device %q (available: %s)", e.Device, strings.Join(DeviceNames(), ", "))
+		}
+		res.device = d
+		if e.Landscape {
+			res.device = d.Landscape()
+		}
+	}
+	if e.UserAgent != "" {
+		res.device.UserAgent = e.UserAgent
+	}
+
+	if e.Locale != "" {
+		if !localePattern.MatchString(e.Locale) {
+			return nil, fmt.Errorf("invalid locale %q (expected e.g. ru-RU or en-US)", e.Locale)
+		}
+		res.locale = strings.ReplaceAll(e.Locale, "_", "-")
+		res.device.AcceptLanguage = acceptLanguage(res.locale)
+	}
+
+	if g := e.Geolocation; g != nil {
+		if g.Latitude < -90 || g.Latitude > 90 || g.Longitude < -180 || g.Longitude > 180 || g.Accuracy < 0 {
+			return nil, fmt.Errorf("invalid geolocation %v,%v", g.Latitude, g.Longitude)
+		}
+		geo := *g
+		if geo.Accuracy == 0 {
+			geo.Accuracy = defaultGeoAccuracy
+		}
+		res.geo = &geo
+	}
+
+	switch strings.ToLower(e.ColorScheme) {
+	case "":
+	case "light", "dark":
+		res.colorScheme = strings.ToLower(e.ColorScheme)
+	default:
+		return nil, fmt.Errorf("invalid color scheme %q (use light or dark)", e.ColorScheme)
+	}
+
+	return res, nil
+}
+
+// acceptLanguage строит Accept-Language для локали: ru-RU → "ru-RU,ru;q=0.9"
+func acceptLanguage(locale string) string {
+	lang, _, found := strings.Cut(locale, "-")
+	if !found {
+		return locale
+	}
+	return locale + "," + lang + ";q=0.9"
+}
+
+// apply настраивает вкладку; nil возвращает её к устройству base и поведению браузера
+// по умолчанию. Переопределения локали и пояса сначала снимаются: повторная установка
+// поверх действующей — ошибка.
+func (e *emulation) apply(page *rod.Page, base devices.Device) error {
+	if e == nil {
+		e = &emulation{device: base}
+	}
+
+	if e.device.IsClear() {
+		if err := clearDevice(page); err != nil {
+			return fmt.Errorf("clear device emulation: %w", err)
+		}
+	} else if err := page.Emulate(e.device); err != nil {
+		return fmt.Errorf("emulate device: %w", err)
+	}
+
+	_ = proto.EmulationSetLocaleOverride{}.Call(page)
+	if e.locale != "" {
+		if err := (proto.EmulationSetLocaleOverride{Locale: e.locale}).Call(page); err != nil {
+			return fmt.Errorf("set locale %s: %w", e.locale, err)
+		}
+	}
+
+	_ = proto.EmulationSetTimezoneOverride{}.Call(page)
+	if e.timezone != "" {
+		if err := (proto.EmulationSetTimezoneOverride{TimezoneID: e.timezone}).Call(page); err != nil {
+			return fmt.Errorf("set timezone %s: %w", e.timezone, err)
+		}
+	}
+
+	if e.geo != nil {
+		err := proto.EmulationSetGeolocationOverride{
+			Latitude:  &e.geo.Latitude,
+			Longitude: &e.geo.Longitude,
+			Accuracy:  &e.geo.Accuracy,
+		}.Call(page)
+		if err != nil {
+			return fmt.Errorf("set geolocation: %w", err)
+		}
+	} else if err := (proto.EmulationClearGeolocationOverride{}).Call(page); err != nil {
+		return fmt.Errorf("clear geolocation: %w", err)
+	}
+
+	// Пустое значение снимает переопределение темы
+	err := proto.EmulationSetEmulatedMedia{
+		Features: []*proto.EmulationMediaFeature{{Name: "prefers-color-scheme", Value: e.colorScheme}},
+	}.Call(page)
+	if err != nil {
+		return fmt.Errorf("set color scheme: %w", err)
+	}
+	return nil
+}
+
+// clearDevice снимает переопределения экрана, касаний и User-Agent: вкладка снова
+// показывает настоящие размеры окна и UA браузера. page.Emulate(devices.Clear) для этого
+// не годится — вместо снятия UA он ставит UA ноутбука rod.
+func clearDevice(page *rod.Page) error {
+	if err := (proto.EmulationClearDeviceMetricsOverride{}).Call(page); err != nil {
+		return err
+	}
+	if err := (proto.EmulationSetTouchEmulationEnabled{Enabled: false}).Call(page); err != nil {
+		return err
+	}
+	// Пустой UA снимает переопределение
+	return proto.NetworkSetUserAgentOverride{}.Call(page)
+}
+
+// resetDevice — устройство вкладок без эмуляции. В подключённом браузере вкладки
+// пользователя возвращаются к своему окну, а не к экрану rod по умолчанию.
+func (m *Manager) resetDevice() devices.Device {
+	if m.config.RemoteURL != "" {
+		return devices.Clear
+	}
+	return baseDevice
+}
+
+// SetEmulation применяет настройки эмуляции ко всем вкладкам и вкладкам, открытым позже;
+// nil возвращает браузеру поведение по умолчанию.
+func (m *Manager) SetEmulation(e *types.Emulation) error {
+	em, err := compileEmulation(e)
+	if err != nil {
+		return err
+	}
+	if em == nil && m.emulation == nil {
+		return nil
+	}
+
+	// Геолокация без разрешения сайту недоступна: выдаём его всем сайтам контекста.
+	// При сбросе разрешения сбрасываются, иначе сайты увидят настоящее местоположение.
+	switch {
+	case em != nil && em.geo != nil:
+		err = proto.BrowserGrantPermissions{
+			Permissions:      []proto.BrowserPermissionType{proto.BrowserPermissionTypeGeolocation},
+			BrowserContextID: m.browser.BrowserContextID,
+		}.Call(m.browser)
+		if err != nil {
+			return fmt.Errorf("grant geolocation permission: %w", err)
+		}
+	case m.emulation != nil && m.emulation.geo != nil:
+		_ = proto.BrowserResetPermissions{BrowserContextID: m.browser.BrowserContextID}.Call(m.browser)
+	}
+
+	m.emulation = em
+	for _, page := range m.tabPages() {
+		if err := em.apply(page, m.resetDevice()); err != nil {
+			return err
+		}
+	}
+
+	if m.config.Debug && e != nil {
+		m.log.Debug("Emulation applied", "device", e.Device, "locale", e.Locale, "timezone", e.Timezone, "colorScheme", e.ColorScheme)
+	}
+	return nil
+}
+
+// OverrideEmulation применяет настройки задачи поверх BrowserConfig.Emulation.
+// restore возвращает настройки конфигурации.
+func (m *Manager) OverrideEmulation(over *types.Emulation) (restore func() error, err error) {
+	if err := m.SetEmulation(m.config.Emulation.Merge(over)); err != nil {
+		_ = m.SetEmulation(m.config.Emulation)
+		return nil, err
+	}
+	return func() error {
+		return m.SetEmulation(m.config.Emulation)
+	}, nil
+}
+
+// emulateTab применяет текущую эмуляцию к новой вкладке
+func (m *Manager) emulateTab(page *rod.Page) {
+	if m.emulation == nil {
+		return
+	}
+	if err := m.emulation.apply(page, m.resetDevice()); err != nil {
+		m.log.Warn("Failed to apply emulation to tab", "error", err.Error())
+	}
+}
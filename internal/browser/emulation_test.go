@@ -0,0 +1,120 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/go-rod/rod/lib/devices"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func TestCompileEmulation(t *testing.T) {
+	em, err := compileEmulation(&types.Emulation{
+		Device:      "iPhone X",
+		Landscape:   true,
+		Locale:      "ru_RU",
+		Timezone:    "Europe/Moscow",
+		Geolocation: &types.Geolocation{Latitude: 55.75, Longitude: 37.62},
+		ColorScheme: "Dark",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := em.device.MetricsEmulation()
+	if !metrics.Mobile || metrics.Width != devices.IPhoneX.Screen.Horizontal.Width || metrics.DeviceScaleFactor != 3 {
+		t.Errorf("metrics = %+v", metrics)
+	}
+	if em.device.UserAgent != devices.IPhoneX.UserAgent {
+		t.Errorf("user agent = %q", em.device.UserAgent)
+	}
+	if em.locale != "ru-RU" || em.device.AcceptLanguage != "ru-RU,ru;q=0.9" {
+		t.Errorf("locale = %q, Accept-Language = %q", em.locale, em.device.AcceptLanguage)
+	}
+	if em.timezone != "Europe/Moscow" || em.colorScheme != "dark" {
+		t.Errorf("timezone/color scheme = %q/%q", em.timezone, em.colorScheme)
+	}
+	if em.geo == nil || em.geo.Accuracy != defaultGeoAccuracy {
+		t.Errorf("geolocation = %+v", em.geo)
+	}
+}
+
+func TestCompileEmulation_Defaults(t *testing.T) {
+	if em, err := compileEmulation(nil); em != nil || err != nil {
+		t.Errorf("compileEmulation(nil) = %+v, %v", em, err)
+	}
+
+	// Без устройства остаётся экран rod по умолчанию, меняются только UA и язык
+	em, err := compileEmulation(&types.Emulation{UserAgent: "TestBot/1.0", Locale: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := em.device.MetricsEmulation()
+	if metrics.Mobile || metrics.Width != baseDevice.MetricsEmulation().Width {
+		t.Errorf("metrics = %+v", metrics)
+	}
+	if em.device.UserAgent != "TestBot/1.0" || em.device.AcceptLanguage != "en" {
+		t.Errorf("device = %q %q", em.device.UserAgent, em.device.AcceptLanguage)
+	}
+}
+
+func TestResetDevice(t *testing.T) {
+	launched := &Manager{config: &types.BrowserConfig{}}
+	if d := launched.resetDevice(); d.IsClear() || d.MetricsEmulation().Width != baseDevice.MetricsEmulation().Width {
+		t.Errorf("launched browser should reset to the rod screen, got %+v", d.MetricsEmulation())
+	}
+
+	// Вкладки пользователя возвращаются к своему окну и UA
+	attached := &Manager{config: &types.BrowserConfig{RemoteURL: "9222"}}
+	if d := attached.resetDevice(); !d.IsClear() {
+		t.Errorf("attached browser should clear the device override, got %+v", d.MetricsEmulation())
+	}
+}
+
+func TestValidateEmulation(t *testing.T) {
+	tests := []struct {
+		name    string
+		e       types.Emulation
+		wantErr bool
+	}{
+		{name: "presets", e: types.Emulation{Device: "pixel-2-xl", ColorScheme: "light"}},
+		{name: "unknown device", e: types.Emulation{Device: "nokia-3310"}, wantErr: true},
+		{name: "bad locale", e: types.Emulation{Locale: "русский"}, wantErr: true},
+		{name: "bad color scheme", e: types.Emulation{ColorScheme: "sepia"}, wantErr: true},
+		{name: "latitude out of range", e: types.Emulation{Geolocation: &types.Geolocation{Latitude: 91}}, wantErr: true},
+		{name: "longitude out of range", e: types.Emulation{Geolocation: &types.Geolocation{Longitude: -181}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidateEmulation(&tt.e); (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseGeolocation(t *testing.T) {
+	geo, err := ParseGeolocation("55.7558, 37.6173,50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if geo.Latitude != 55.7558 || geo.Longitude != 37.6173 || geo.Accuracy != 50 {
+		t.Errorf("ParseGeolocation() = %+v", geo)
+	}
+
+	for _, raw := range []string{"55.75", "55.75,north", "1,2,3,4"} {
+		if _, err := ParseGeolocation(raw); err == nil {
+			t.Errorf("ParseGeolocation(%q) returned no error", raw)
+		}
+	}
+}
+
+func TestDeviceNames(t *testing.T) {
+	names := DeviceNames()
+	if len(names) != len(devicePresets) {
+		t.Fatalf("names = %d, want %d", len(names), len(devicePresets))
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Errorf("names not sorted: %v", names)
+			break
+		}
+	}
+}
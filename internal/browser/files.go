@@ -0,0 +1,355 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+const (
+	// fileChooserWait — сколько ждать окна выбора файла после клика по кнопке загрузки
+	fileChooserWait = 5 * time.Second
+	// maxListedFiles — сколько файлов рабочего каталога перечислять в ошибке
+	maxListedFiles = 30
+)
+
+// fileInputJS находит input[type=file] для элемента: сам элемент, связанный label, вложенный
+// input, единственный файловый input формы или страницы. Скрытые input у кнопок «Прикрепить»
+// в extract_page не попадают, поэтому модель передаёт ID видимой кнопки.
+const fileInputJS = `(id) => {
+	const el = window._ai_resolve(id);
+	if (!el) {
+		throw new Error("Element ID " + id + " is no longer on the page. Call extract_page again.");
+	}
+	const isFile = (n) => n && n.tagName === "INPUT" && n.type === "file";
+	if (isFile(el)) return el;
+	if (isFile(el.control)) return el.control;
+	const inner = el.querySelector("input[type=file]");
+	if (inner) return inner;
+	for (const scope of [el.closest("form"), el.getRootNode()]) {
+		if (!scope) continue;
+		const inputs = scope.querySelectorAll("input[type=file]");
+		if (inputs.length === 1) return inputs[0];
+	}
+	return null;
+}`
+
+// UploadFile прикрепляет файл из рабочего каталога к полю загрузки, связанному с элементом.
+// Если файлового input рядом нет, кликает элемент и перехватывает окно выбора файла.
+// Возвращает полный путь прикреплённого файла.
+func (m *Manager) UploadFile(ctx context.Context, id int, path string) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	file, err := resolveWorkspacePath(m.config.WorkspaceDir, path)
+	if err != nil {
+		return "", err
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Uploading file", "id", id, "path", file)
+	}
+
+	w := m.watchSettle()
+
+	frame := m.frameForElement(id)
+	obj, err := frame.Evaluate(rod.Eval(fileInputJS, id).ByObject())
+	if err != nil {
+		w.cancel()
+		return "", fmt.Errorf("find file input for element [%d]: %w", id, err)
+	}
+
+	if obj.ObjectID != "" {
+		el, err := frame.ElementFromObject(obj)
+		if err == nil {
+			// Обработчик change может показать alert о неподходящем файле
+			_, err = untilDialog(w, func(context.Context) (struct{}, error) {
+				return struct{}{}, el.SetFiles([]string{file})
+			})
+		}
+		if err != nil {
+			w.cancel()
+			return "", fmt.Errorf("set file of element [%d]: %w", id, err)
+		}
+	} else if _, err := untilDialog(w, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, m.uploadViaFileChooser(ctx, id, file)
+	}); err != nil {
+		w.cancel()
+		return "", err
+	}
+
+	m.settle(ctx, w)
+	return file, nil
+}
+
+// uploadViaFileChooser кликает элемент и отдаёт файл в открывшееся окно выбора файла
+func (m *Manager) uploadViaFileChooser(ctx context.Context, id int, file string) error {
+	page := m.activePage()
+	setFiles, err := page.Timeout(fileChooserWait).HandleFileDialog()
+	if err != nil {
+		return fmt.Errorf("intercept file chooser: %w", err)
+	}
+	// Перехват должен выключиться при любом исходе, иначе окно выбора файла перестанет открываться
+	defer func() { _ = proto.PageSetInterceptFileChooserDialog{Enabled: false}.Call(page) }()
+
+	if _, err := m.clickElement(ctx, id); err != nil {
+		return fmt.Errorf("click element [%d]: %w", id, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := setFiles([]string{file}); err != nil {
+		return fmt.Errorf("element [%d] is not a file upload field and did not open a file chooser", id)
+	}
+	return nil
+}
+
+// resolveWorkspacePath проверяет, что путь указывает на файл внутри рабочего каталога.
+// Относительные пути считаются от рабочего каталога, символические ссылки раскрываются.
+func resolveWorkspacePath(workspace, path string) (string, error) {
+	if workspace == "" {
+		return "", fmt.Errorf("file upload is disabled: no workspace directory configured")
+	}
+
+	root, err := filepath.Abs(workspace)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return "", fmt.Errorf("workspace directory %s is not available: %w", workspace, err)
+	}
+
+	file := path
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(root, file)
+	}
+	file, err = filepath.EvalSymlinks(file)
+	if err != nil {
+		return "", fmt.Errorf("file %q not found in the workspace. Available files: %s", path, strings.Join(workspaceFiles(root), ", "))
+	}
+
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file %q is outside the workspace directory", path)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", fmt.Errorf("file %q: %w", path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%q is a directory, not a file", path)
+	}
+
+	return file, nil
+}
+
+// workspaceFiles перечисляет файлы рабочего каталога относительными путями
+func workspaceFiles(root string) []string {
+	var files []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if len(files) == maxListedFiles {
+			return filepath.SkipAll
+		}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if len(files) == 0 {
+		return []string{"(none)"}
+	}
+	return files
+}
+
+// downloadRegistry — загрузки текущей задачи. Браузер сохраняет файл под GUID,
+// после завершения он переименовывается в предложенное сайтом имя.
+type downloadRegistry struct {
+	mu     sync.Mutex
+	dir    string
+	items  []*types.DownloadInfo
+	byGUID map[string]*types.DownloadInfo
+	// started — загрузки, начавшиеся после последнего TakeDownloadEvents
+	started []types.DownloadInfo
+}
+
+// SetDownloadDir направляет загрузки браузера в каталог и начинает новый список загрузок
+func (m *Manager) SetDownloadDir(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve downloads dir: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return fmt.Errorf("create downloads dir: %w", err)
+	}
+
+	err = proto.BrowserSetDownloadBehavior{
+		Behavior:         proto.BrowserSetDownloadBehaviorBehaviorAllowAndName,
+		BrowserContextID: m.browser.BrowserContextID,
+		DownloadPath:     abs,
+		EventsEnabled:    true,
+	}.Call(m.browser)
+	if err != nil {
+		return fmt.Errorf("set download behavior: %w", err)
+	}
+
+	m.downloads.mu.Lock()
+	defer m.downloads.mu.Unlock()
+	m.downloads.dir = abs
+	m.downloads.items = nil
+	m.downloads.byGUID = map[string]*types.DownloadInfo{}
+	m.downloads.started = nil
+	return nil
+}
+
+// resetDownloadBehavior возвращает браузеру обычное поведение загрузок. Нужно для чужого
+// браузера (RemoteURL): после отключения агента он не должен сохранять файлы в каталог артефактов.
+func (m *Manager) resetDownloadBehavior() {
+	m.downloads.mu.Lock()
+	dir := m.downloads.dir
+	m.downloads.mu.Unlock()
+	if dir == "" {
+		return
+	}
+
+	err := proto.BrowserSetDownloadBehavior{
+		Behavior:         proto.BrowserSetDownloadBehaviorBehaviorDefault,
+		BrowserContextID: m.browser.BrowserContextID,
+	}.Call(m.browser)
+	if err != nil {
+		m.log.Warn("Failed to restore download behavior", "error", err)
+	}
+}
+
+// watchDownloads следит за загрузками браузера до его закрытия
+func (m *Manager) watchDownloads() {
+	m.browser.EachEvent(func(e *proto.BrowserDownloadWillBegin) {
+		// События загрузок приходят от всего браузера: инкогнито-контекст берёт только свои
+		if m.browser.BrowserContextID != "" && !m.ownsFrame(e.FrameID) {
+			return
+		}
+		m.downloads.begin(e)
+	}, func(e *proto.BrowserDownloadProgress) {
+		m.downloads.progress(e)
+	})()
+}
+
+func (r *downloadRegistry) begin(e *proto.BrowserDownloadWillBegin) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.byGUID == nil {
+		return
+	}
+
+	d := &types.DownloadInfo{
+		Name:  e.SuggestedFilename,
+		URL:   e.URL,
+		State: types.DownloadInProgress,
+	}
+	r.items = append(r.items, d)
+	r.byGUID[e.GUID] = d
+	r.started = append(r.started, *d)
+}
+
+func (r *downloadRegistry) progress(e *proto.BrowserDownloadProgress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d, ok := r.byGUID[e.GUID]
+	if !ok {
+		return
+	}
+	d.Size = int64(e.ReceivedBytes)
+
+	switch e.State {
+	case proto.BrowserDownloadProgressStateCompleted:
+		d.State = types.DownloadCompleted
+		src := filepath.Join(r.dir, e.GUID)
+		dst := uniquePath(r.dir, d.Name)
+		if err := os.Rename(src, dst); err != nil {
+			dst = src
+		}
+		d.Path = dst
+		d.Name = filepath.Base(dst)
+		d.MIME = detectMIME(dst)
+		delete(r.byGUID, e.GUID)
+	case proto.BrowserDownloadProgressStateCanceled:
+		d.State = types.DownloadCanceled
+		delete(r.byGUID, e.GUID)
+	}
+}
+
+// ListDownloads возвращает загрузки текущей задачи в порядке начала
+func (m *Manager) ListDownloads() []types.DownloadInfo {
+	m.downloads.mu.Lock()
+	defer m.downloads.mu.Unlock()
+
+	list := make([]types.DownloadInfo, 0, len(m.downloads.items))
+	for _, d := range m.downloads.items {
+		list = append(list, *d)
+	}
+	return list
+}
+
+// TakeDownloadEvents возвращает загрузки, начавшиеся с прошлого вызова, и сбрасывает их
+func (m *Manager) TakeDownloadEvents() []types.DownloadInfo {
+	m.downloads.mu.Lock()
+	defer m.downloads.mu.Unlock()
+
+	started := m.downloads.started
+	m.downloads.started = nil
+	return started
+}
+
+// uniquePath возвращает путь для файла в каталоге, не затирая существующие: report.pdf, report (1).pdf, ...
+func uniquePath(dir, name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || name == "." || name == "/" {
+		name = "download"
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	path := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", stem, i, ext))
+	}
+}
+
+// detectMIME определяет тип файла по расширению, а без него — по содержимому
+func detectMIME(path string) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := f.Read(head)
+	return http.DetectContentType(head[:n])
+}
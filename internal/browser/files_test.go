@@ -0,0 +1,129 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func TestResolveWorkspacePath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{filepath.Join(root, "cv.pdf"), filepath.Join(root, "docs", "letter.txt"), filepath.Join(outside, "secret.txt")} {
+		if err := os.WriteFile(f, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := resolveWorkspacePath(root, "docs/letter.txt")
+	if err != nil || filepath.Base(got) != "letter.txt" {
+		t.Errorf("relative path: got %q, %v", got, err)
+	}
+	if _, err := resolveWorkspacePath(root, filepath.Join(root, "cv.pdf")); err != nil {
+		t.Errorf("absolute path inside workspace: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		workspace string
+		path      string
+		want      string
+	}{
+		{"no workspace", "", "cv.pdf", "disabled"},
+		{"parent dir", root, "../secret.txt", "not found"},
+		{"escape via dots", root, "docs/../../" + filepath.Base(outside) + "/secret.txt", "outside the workspace"},
+		{"absolute outside", root, filepath.Join(outside, "secret.txt"), "outside the workspace"},
+		{"symlink outside", root, "link.txt", "outside the workspace"},
+		{"directory", root, "docs", "directory"},
+		{"missing lists files", root, "resume.pdf", "cv.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolveWorkspacePath(tt.workspace, tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestUniquePath(t *testing.T) {
+	dir := t.TempDir()
+
+	if got := uniquePath(dir, "report.pdf"); got != filepath.Join(dir, "report.pdf") {
+		t.Errorf("free name: got %s", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "report.pdf"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := uniquePath(dir, "report.pdf"); got != filepath.Join(dir, "report (1).pdf") {
+		t.Errorf("taken name: got %s", got)
+	}
+	if got := uniquePath(dir, "../../etc/passwd"); got != filepath.Join(dir, "passwd") {
+		t.Errorf("path in suggested name must be dropped: got %s", got)
+	}
+	if got := uniquePath(dir, ""); got != filepath.Join(dir, "download") {
+		t.Errorf("empty name: got %s", got)
+	}
+}
+
+func TestDownloadRegistry(t *testing.T) {
+	dir := t.TempDir()
+	r := &downloadRegistry{dir: dir, byGUID: map[string]*types.DownloadInfo{}}
+
+	r.begin(&proto.BrowserDownloadWillBegin{GUID: "g1", URL: "https://example.com/invoice", SuggestedFilename: "invoice.pdf"})
+	r.begin(&proto.BrowserDownloadWillBegin{GUID: "g2", URL: "https://example.com/big.zip", SuggestedFilename: "big.zip"})
+	if len(r.started) != 2 || r.items[0].State != types.DownloadInProgress {
+		t.Fatalf("expected two started downloads, got %+v", r.started)
+	}
+
+	// Браузер сохраняет файл под GUID
+	if err := os.WriteFile(filepath.Join(dir, "g1"), []byte("%PDF-1.4"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r.progress(&proto.BrowserDownloadProgress{GUID: "g1", ReceivedBytes: 8, State: proto.BrowserDownloadProgressStateCompleted})
+	r.progress(&proto.BrowserDownloadProgress{GUID: "g2", ReceivedBytes: 100, State: proto.BrowserDownloadProgressStateCanceled})
+
+	done := r.items[0]
+	if done.State != types.DownloadCompleted || done.Path != filepath.Join(dir, "invoice.pdf") || done.Size != 8 || done.MIME != "application/pdf" {
+		t.Errorf("unexpected completed download: %+v", done)
+	}
+	if _, err := os.Stat(done.Path); err != nil {
+		t.Errorf("file should be renamed to the suggested name: %v", err)
+	}
+	if r.items[1].State != types.DownloadCanceled {
+		t.Errorf("expected canceled download, got %+v", r.items[1])
+	}
+
+	// Загрузки до SetDownloadDir не отслеживаются
+	idle := &downloadRegistry{}
+	idle.begin(&proto.BrowserDownloadWillBegin{GUID: "g3"})
+	if len(idle.items) != 0 {
+		t.Error("downloads without a directory should be ignored")
+	}
+}
+
+func TestDetectMIME(t *testing.T) {
+	dir := t.TempDir()
+	noExt := filepath.Join(dir, "file")
+	if err := os.WriteFile(noExt, []byte("%PDF-1.4 content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := detectMIME(noExt); got != "application/pdf" {
+		t.Errorf("sniffed type: got %s", got)
+	}
+	if got := detectMIME(filepath.Join(dir, "missing.csv")); !strings.HasPrefix(got, "text/csv") {
+		t.Errorf("type by extension: got %s", got)
+	}
+}
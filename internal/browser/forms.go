@@ -0,0 +1,283 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// valueFormats — типы input, которые не принимают набранный текст: значение ставится напрямую
+// и должно быть в формате HTML. Формат подсказывается модели, если браузер отверг значение.
+var valueFormats = map[string]string{
+	"date":           "YYYY-MM-DD",
+	"time":           "HH:MM",
+	"datetime-local": "YYYY-MM-DDTHH:MM",
+	"month":          "YYYY-MM",
+	"week":           "YYYY-Www",
+	"range":          "a number between min and max",
+	"color":          "#rrggbb",
+}
+
+// selectOptionJS выбирает вариант select по value, затем по подписи (точно, потом по вхождению)
+const selectOptionJS = `(args) => {
+	const el = window._ai_resolve(args.id);
+	if (!el) {
+		throw new Error("Element ID " + args.id + " is no longer on the page. Call extract_page again.");
+	}
+	if (el.tagName !== "SELECT") {
+		throw new Error("Element [" + args.id + "] is not a <select>. For a custom dropdown click it, then click the option in the opened list.");
+	}
+
+	const options = Array.from(el.options);
+	const norm = (s) => (s || "").trim().replace(/\s+/g, " ").toLowerCase();
+	const byLabel = (label) => {
+		const want = norm(label);
+		if (!want) return null;
+		return options.find(o => norm(o.label || o.text) === want) ||
+			options.find(o => norm(o.label || o.text).includes(want)) || null;
+	};
+
+	let option = null;
+	if (args.value !== "") option = options.find(o => o.value === args.value) || null;
+	if (!option) option = byLabel(args.label);
+	// Модели часто передают подпись варианта в value
+	if (!option) option = byLabel(args.value);
+	if (!option) {
+		const available = options.slice(0, 20).map(o => JSON.stringify((o.label || o.text).trim())).join(", ");
+		throw new Error("No option matches. Available options: " + available);
+	}
+	if (option.disabled) {
+		throw new Error("Option " + JSON.stringify((option.label || option.text).trim()) + " is disabled");
+	}
+
+	el.scrollIntoView({block: "center"});
+	el.focus();
+	if (el.multiple) {
+		option.selected = true;
+	} else {
+		const setter = Object.getOwnPropertyDescriptor(HTMLSelectElement.prototype, "value").set;
+		setter.call(el, option.value);
+	}
+	el.dispatchEvent(new Event("input", {bubbles: true}));
+	el.dispatchEvent(new Event("change", {bubbles: true}));
+	return {value: option.value, label: (option.label || option.text).trim()};
+}`
+
+// checkedStateJS возвращает вид переключателя и его состояние. Понимает нативные checkbox/radio,
+// label и обёртки с input внутри, а также ARIA-переключатели (role=checkbox, switch).
+const checkedStateJS = `(id) => {
+	const el = window._ai_resolve(id);
+	if (!el) {
+		throw new Error("Element ID " + id + " is no longer on the page. Call extract_page again.");
+	}
+	const isToggle = (n) => n && n.tagName === "INPUT" && (n.type === "checkbox" || n.type === "radio");
+	const input = isToggle(el) ? el : (isToggle(el.control) ? el.control : el.querySelector("input[type=checkbox], input[type=radio]"));
+	if (input) {
+		return {kind: input.type, checked: input.checked};
+	}
+	const aria = el.getAttribute("aria-checked") ?? el.getAttribute("aria-pressed");
+	if (aria === "true" || aria === "false" || aria === "mixed") {
+		return {kind: el.getAttribute("role") || "checkbox", checked: aria === "true"};
+	}
+	throw new Error("Element [" + id + "] is not a checkbox, radio button or switch");
+}`
+
+// toggleJS — запасное переключение: el.click() по самому input, если элемент — его обёртка или label
+const toggleJS = `(id) => {
+	const el = window._ai_resolve(id);
+	if (!el) {
+		throw new Error("Element ID " + id + " is no longer on the page. Call extract_page again.");
+	}
+	const isToggle = (n) => n && n.tagName === "INPUT" && (n.type === "checkbox" || n.type === "radio");
+	const input = isToggle(el) ? el : (isToggle(el.control) ? el.control : el.querySelector("input[type=checkbox], input[type=radio]"));
+	(input || el).click();
+	return true;
+}`
+
+// inputKindJS возвращает тип поля: select, тип input или пустую строку
+const inputKindJS = `(id) => {
+	const el = window._ai_resolve(id);
+	if (!el) return "";
+	if (el.tagName === "SELECT") return "select";
+	return el.tagName === "INPUT" ? el.type : "";
+}`
+
+// setInputValueJS ставит значение полям дат, range и color и возвращает то, что принял браузер:
+// некорректная дата превращается в пустую строку, range прижимается к min/max/step
+const setInputValueJS = `(args) => {
+	const el = window._ai_resolve(args.id);
+	if (!el) {
+		throw new Error("Element ID " + args.id + " is no longer on the page. Call extract_page again.");
+	}
+	el.scrollIntoView({block: "center"});
+	el.focus();
+	const setter = Object.getOwnPropertyDescriptor(HTMLInputElement.prototype, "value").set;
+	setter.call(el, args.value);
+	el.dispatchEvent(new Event("input", {bubbles: true}));
+	el.dispatchEvent(new Event("change", {bubbles: true}));
+	el.blur();
+	return el.value;
+}`
+
+// SelectOption выбирает вариант select по value или подписи и возвращает выбранный вариант
+func (m *Manager) SelectOption(ctx context.Context, id int, value, label string) (types.SelectOption, error) {
+	select {
+	case <-ctx.Done():
+		return types.SelectOption{}, ctx.Err()
+	default:
+	}
+
+	if value == "" && label == "" {
+		return types.SelectOption{}, fmt.Errorf("value or label is required")
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Selecting option", "id", id, "value", value, "label", label)
+	}
+
+	w := m.watchSettle()
+	res, err := untilDialog(w, func(context.Context) (*proto.RuntimeRemoteObject, error) {
+		return m.frameForElement(id).Eval(selectOptionJS, map[string]interface{}{"id": id, "value": value, "label": label})
+	})
+	if err != nil {
+		w.cancel()
+		return types.SelectOption{}, fmt.Errorf("select option in element [%d]: %w", id, err)
+	}
+	m.settle(ctx, w)
+
+	// Обработчик change открыл диалог — выбранный вариант узнаем после ответа на него
+	if res == nil {
+		return types.SelectOption{Value: value, Label: label}, nil
+	}
+
+	return types.SelectOption{
+		Value:    res.Value.Get("value").Str(),
+		Label:    res.Value.Get("label").Str(),
+		Selected: true,
+	}, nil
+}
+
+// SetChecked приводит чекбокс, радиокнопку или переключатель в нужное состояние кликом
+// (нативным, с запасным JS). Пустой Method в результате — элемент уже был в этом состоянии.
+func (m *Manager) SetChecked(ctx context.Context, id int, checked bool) (types.InputResult, error) {
+	select {
+	case <-ctx.Done():
+		return types.InputResult{}, ctx.Err()
+	default:
+	}
+
+	frame := m.frameForElement(id)
+	state, err := frame.Eval(checkedStateJS, id)
+	if err != nil {
+		return types.InputResult{}, fmt.Errorf("read state of element [%d]: %w", id, err)
+	}
+	if state.Value.Get("checked").Bool() == checked {
+		return types.InputResult{}, nil
+	}
+	if !checked && state.Value.Get("kind").Str() == "radio" {
+		return types.InputResult{}, fmt.Errorf("a radio button cannot be unchecked: select another option of the group")
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Toggling element", "id", id, "checked", checked)
+	}
+
+	w := m.watchSettle()
+	res, err := untilDialog(w, func(ctx context.Context) (types.InputResult, error) {
+		return m.clickElement(ctx, id)
+	})
+	if err != nil {
+		w.cancel()
+		return types.InputResult{}, fmt.Errorf("click element [%d]: %w", id, err)
+	}
+	m.settle(ctx, w)
+
+	// Пока открыт диалог, состояние не прочитать: страница ждёт ответа
+	if w.dialogOpened() {
+		return res, nil
+	}
+
+	state, err = m.frameForElement(id).Eval(checkedStateJS, id)
+	if err != nil {
+		return types.InputResult{}, fmt.Errorf("read state of element [%d]: %w", id, err)
+	}
+	if state.Value.Get("checked").Bool() == checked {
+		return res, nil
+	}
+
+	// Нативный клик мог попасть в обёртку, которая не переключает состояние
+	if res.Method == types.InputNative {
+		w := m.watchSettle()
+		if _, err := m.frameForElement(id).Eval(toggleJS, id); err != nil {
+			w.cancel()
+			return types.InputResult{}, fmt.Errorf("click element [%d]: %w", id, err)
+		}
+		m.settle(ctx, w)
+
+		state, err = m.frameForElement(id).Eval(checkedStateJS, id)
+		if err == nil && state.Value.Get("checked").Bool() == checked {
+			return types.InputResult{Method: types.InputJS, Fallback: "native click did not change the state"}, nil
+		}
+	}
+
+	return types.InputResult{}, fmt.Errorf("element [%d] did not change its state after click", id)
+}
+
+// setInputValue ставит значение полю, которое не принимает набранный текст (даты, range, color).
+// Возвращает значение, принятое браузером.
+func (m *Manager) setInputValue(id int, kind, text string) (string, error) {
+	value := normalizeInputValue(kind, text)
+
+	res, err := m.frameForElement(id).Eval(setInputValueJS, map[string]interface{}{"id": id, "value": value})
+	if err != nil {
+		return "", err
+	}
+
+	accepted := res.Value.Str()
+	if accepted == "" && value != "" {
+		return "", fmt.Errorf("value %q rejected: input type=%s expects %s", text, kind, valueFormats[kind])
+	}
+	return accepted, nil
+}
+
+var (
+	// dd.mm.yyyy, dd/mm/yyyy, dd-mm-yyyy
+	dayFirstDate = regexp.MustCompile(`^(\d{1,2})[./-](\d{1,2})[./-](\d{4})$`)
+	// yyyy/mm/dd, yyyy.mm.dd, yyyy-m-d
+	yearFirstDate = regexp.MustCompile(`^(\d{4})[./-](\d{1,2})[./-](\d{1,2})$`)
+)
+
+// normalizeInputValue приводит распространённые записи дат к формату HTML (YYYY-MM-DD),
+// остальные значения возвращает как есть
+func normalizeInputValue(kind, text string) string {
+	text = strings.TrimSpace(text)
+
+	if kind != "date" && kind != "datetime-local" {
+		return text
+	}
+
+	date, rest := text, ""
+	if kind == "datetime-local" {
+		if i := strings.IndexAny(text, "T "); i > 0 {
+			date, rest = text[:i], "T"+strings.TrimSpace(text[i+1:])
+		}
+	}
+
+	if m := dayFirstDate.FindStringSubmatch(date); m != nil {
+		date = isoDate(m[3], m[2], m[1])
+	} else if m := yearFirstDate.FindStringSubmatch(date); m != nil {
+		date = isoDate(m[1], m[2], m[3])
+	}
+	return date + rest
+}
+
+func isoDate(year, month, day string) string {
+	mm, _ := strconv.Atoi(month)
+	dd, _ := strconv.Atoi(day)
+	return fmt.Sprintf("%s-%02d-%02d", year, mm, dd)
+}
@@ -0,0 +1,28 @@
+package browser
+
+import "testing"
+
+func TestNormalizeInputValue(t *testing.T) {
+	tests := []struct {
+		kind string
+		text string
+		want string
+	}{
+		{"date", "2024-03-05", "2024-03-05"},
+		{"date", "5.3.2024", "2024-03-05"},
+		{"date", "05/03/2024", "2024-03-05"},
+		{"date", "2024/3/5", "2024-03-05"},
+		{"date", " 2024-03-05 ", "2024-03-05"},
+		{"date", "March 5", "March 5"},
+		{"datetime-local", "05.03.2024 14:30", "2024-03-05T14:30"},
+		{"datetime-local", "2024-03-05T14:30", "2024-03-05T14:30"},
+		{"range", "42", "42"},
+		{"time", "09:15", "09:15"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeInputValue(tt.kind, tt.text); got != tt.want {
+			t.Errorf("normalizeInputValue(%q, %q) = %q, want %q", tt.kind, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package browser
+
+import (
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// maxFrameDepth ограничивает вложенность iframe при обходе
+const maxFrameDepth = 3
+
+// iframesJS находит все iframe документа, включая лежащие внутри открытых shadow root
+const iframesJS = `() => {
+	const frames = [];
+	const walk = (root) => {
+		root.querySelectorAll('*').forEach(el => {
+			const tag = el.tagName.toLowerCase();
+			if (tag === 'iframe' || tag === 'frame') frames.push(el);
+			if (el.shadowRoot) walk(el.shadowRoot);
+		});
+	};
+	walk(document);
+	return frames;
+}`
+
+// frameTabs — сессии cross-origin iframe и сессии вкладок, в которых они лежат.
+// JS-диалог такого фрейма приходит в сессию фрейма, хотя блокирует работу со всей вкладкой.
+var frameTabs sync.Map
+
+// tabSession возвращает сессию вкладки, которой принадлежит сессия фрейма
+func tabSession(session proto.TargetSessionID) proto.TargetSessionID {
+	if tab, ok := frameTabs.Load(session); ok {
+		return tab.(proto.TargetSessionID)
+	}
+	return session
+}
+
+// Frame — фрейм вкладки и смещение его viewport относительно viewport вкладки
+type Frame struct {
+	Page    *rod.Page
+	OffsetX float64
+	OffsetY float64
+}
+
+// Frames возвращает страницу и все её вложенные фреймы (same-origin и cross-origin)
+// в порядке обхода в глубину. Первым элементом всегда идёт сама страница.
+func Frames(page *rod.Page) []Frame {
+	frames := []Frame{{Page: page}}
+	collectFrames(frames[0], page.SessionID, 0, &frames)
+	return frames
+}
+
+func collectFrames(parent Frame, tab proto.TargetSessionID, depth int, out *[]Frame) {
+	if depth >= maxFrameDepth {
+		return
+	}
+
+	iframes, err := parent.Page.ElementsByJS(rod.Eval(iframesJS))
+	if err != nil {
+		return
+	}
+
+	for _, el := range iframes {
+		page, err := framePage(el)
+		if err != nil {
+			continue
+		}
+		if page.SessionID != tab {
+			frameTabs.Store(page.SessionID, tab)
+		}
+
+		frame := Frame{Page: page, OffsetX: parent.OffsetX, OffsetY: parent.OffsetY}
+		if res, err := el.Eval(`() => {
+			const r = this.getBoundingClientRect();
+			return {x: r.left + this.clientLeft, y: r.top + this.clientTop};
+		}`); err == nil {
+			frame.OffsetX += res.Value.Get("x").Num()
+			frame.OffsetY += res.Value.Get("y").Num()
+		}
+
+		*out = append(*out, frame)
+		collectFrames(frame, tab, depth+1, out)
+	}
+}
+
+// framePage возвращает страницу содержимого iframe. Cross-origin iframe при изоляции сайтов
+// живёт в отдельном процессе: документа в DOM родителя у него нет, и rod подключается
+// к нему как к отдельной цели с тем же ID, что и у фрейма.
+func framePage(el *rod.Element) (*rod.Page, error) {
+	page, err := el.Frame()
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := el.Describe(1, true)
+	if err != nil {
+		return nil, err
+	}
+	if node.ContentDocument != nil {
+		return page, nil
+	}
+	return page.Browser().PageFromTarget(proto.TargetTargetID(node.FrameID))
+}
+
+// frameForElement находит фрейм, в котором extract_page зарегистрировал элемент с данным ID.
+// Если элемент не найден ни в одном фрейме, возвращается основная страница,
+// чтобы JS вернул понятную ошибку.
+func (m *Manager) frameForElement(id int) *rod.Page {
+	return m.elementFrame(id).Page
+}
+
+// elementFrame — как frameForElement, но вместе со смещением фрейма во вкладке
+func (m *Manager) elementFrame(id int) Frame {
+	page := m.activePage()
+	for _, frame := range Frames(page) {
+		res, err := frame.Page.Eval(`(id) => !!(window._ai_elements && window._ai_elements[id])`, id)
+		if err != nil {
+			continue
+		}
+		if res.Value.Bool() {
+			return frame
+		}
+	}
+	return Frame{Page: page}
+}
@@ -0,0 +1,87 @@
+package browser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// launchTestBrowser запускает headless Chrome для тестов или пропускает тест, если Chrome не установлен
+func launchTestBrowser(t *testing.T) *rod.Browser {
+	t.Helper()
+	bin, ok := launcher.LookPath()
+	if !ok {
+		t.Skip("chrome not found")
+	}
+
+	l := launcher.New().Bin(bin).Headless(true).NoSandbox(true)
+	u, err := l.Launch()
+	if err != nil {
+		t.Skipf("chrome cannot start: %v", err)
+	}
+	b := rod.New().ControlURL(u)
+	if err := b.Connect(); err != nil {
+		l.Kill()
+		t.Fatalf("connect to chrome: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = b.Close()
+		l.Kill()
+	})
+	return b
+}
+
+func TestFrames_CrossOrigin(t *testing.T) {
+	b := launchTestBrowser(t)
+
+	child := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body><button>Inside frame</button></body></html>`))
+	}))
+	defer child.Close()
+
+	// localhost и 127.0.0.1 — разные сайты, поэтому iframe уходит в отдельный процесс
+	childURL := strings.Replace(child.URL, "127.0.0.1", "localhost", 1)
+	parent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<html><body><p>Top</p><iframe src="%s/"></iframe></body></html>`, childURL)
+	}))
+	defer parent.Close()
+
+	page, err := b.Page(proto.TargetCreateTarget{URL: parent.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := page.WaitLoad(); err != nil {
+		t.Fatal(err)
+	}
+
+	var frames []Frame
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		frames = Frames(page)
+		if len(frames) == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("expected page and cross-origin iframe, got %d frames", len(frames))
+	}
+
+	res, err := frames[1].Page.Eval(`() => document.querySelector('button').innerText`)
+	if err != nil {
+		t.Fatalf("eval in cross-origin frame: %v", err)
+	}
+	if got := res.Value.Str(); got != "Inside frame" {
+		t.Errorf("frame content = %q", got)
+	}
+	if frames[1].OffsetY <= 0 {
+		t.Errorf("frame offset should be below the paragraph, got %v", frames[1].OffsetY)
+	}
+}
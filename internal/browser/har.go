@@ -0,0 +1,717 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+const (
+	// defaultHARMaxBody — предел сохраняемого тела ответа по умолчанию
+	defaultHARMaxBody = 1 << 20
+	// harRedacted — значение скрытых заголовков и cookies
+	harRedacted = "[redacted]"
+)
+
+// harBodyWait — сколько StopHAR ждёт тел ответов, запрошенных у браузера; меняется в тестах
+var harBodyWait = 5 * time.Second
+
+// harSecretHeaders — заголовки, значения которых по умолчанию не попадают в HAR
+var harSecretHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// Структуры формата HAR 1.2 (http://www.softwareishard.com/blog/har-12-spec/).
+// Поля с "_" — расширения в духе Chrome DevTools.
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Pages   []struct{}  `json:"pages"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	ResourceType    string      `json:"_resourceType,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// harTimings — фазы запроса в мс; -1 — фаза не применима (соединение переиспользовано, ответ из кэша)
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// harKey — запрос вкладки: ID запросов уникальны только в пределах сессии CDP
+type harKey struct {
+	session proto.TargetSessionID
+	id      proto.NetworkRequestID
+}
+
+// harCall — запрос в процессе записи: HAR-запись и отметки времени CDP для расчёта фаз
+type harCall struct {
+	entry    *harEntry
+	started  proto.MonotonicTime
+	response proto.MonotonicTime
+	timing   *proto.NetworkResourceTiming
+	done     bool
+	// Исходные заголовки: к ним добавляются заголовки ExtraInfo
+	reqHeaders  proto.NetworkHeaders
+	respHeaders proto.NetworkHeaders
+}
+
+// harRecorder собирает HAR из событий Network вкладок менеджера
+type harRecorder struct {
+	cfg types.HARConfig
+	// fetchBody получает тело ответа у браузера; подменяется в тестах
+	fetchBody func(session proto.TargetSessionID, id proto.NetworkRequestID) (*proto.NetworkGetResponseBodyResult, error)
+
+	mu     sync.Mutex
+	active bool
+	// gen — номер записи: тела, пришедшие после её конца, отбрасываются
+	gen      int
+	sessions map[proto.TargetSessionID]bool
+	entries  []*harEntry
+	calls    map[harKey]*harCall
+	// extra — заголовки ExtraInfo, пришедшие раньше самого запроса или ответа
+	extraReq  map[harKey]proto.NetworkHeaders
+	extraResp map[harKey]proto.NetworkHeaders
+
+	// bodies — загрузки тел ответов текущей записи. У каждой записи своя группа:
+	// загрузки, не дождавшиеся конца прежней записи, не мешают ждать новой.
+	bodies *sync.WaitGroup
+}
+
+func newHARRecorder(cfg types.HARConfig) *harRecorder {
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultHARMaxBody
+	}
+	return &harRecorder{cfg: cfg, sessions: map[proto.TargetSessionID]bool{}}
+}
+
+// track начинает учитывать запросы вкладки
+func (r *harRecorder) track(session proto.TargetSessionID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session] = true
+}
+
+// start начинает новую запись, отбрасывая прежнюю
+func (r *harRecorder) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active = true
+	r.gen++
+	r.bodies = &sync.WaitGroup{}
+	r.entries = nil
+	r.calls = map[harKey]*harCall{}
+	r.extraReq = map[harKey]proto.NetworkHeaders{}
+	r.extraResp = map[harKey]proto.NetworkHeaders{}
+}
+
+// stop завершает запись и возвращает HAR. Незавершённые запросы попадают в него как есть.
+func (r *harRecorder) stop() *harFile {
+	r.mu.Lock()
+	r.active = false
+	bodies := r.bodies
+	r.bodies = nil
+	r.mu.Unlock()
+
+	// Тела запрашиваются у браузера асинхронно — даём им прийти
+	if bodies != nil {
+		waited := make(chan struct{})
+		go func() {
+			bodies.Wait()
+			close(waited)
+		}()
+		select {
+		case <-waited:
+		case <-time.After(harBodyWait):
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gen++
+	for _, c := range r.calls {
+		if !c.done && c.entry.Error == "" {
+			c.entry.Error = "not finished before the end of the run"
+		}
+	}
+	entries := r.entries
+	if entries == nil {
+		entries = []*harEntry{}
+	}
+	r.entries = nil
+	r.calls = nil
+	return &harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "ai-browser-assistant", Version: "1.0"},
+		Pages:   []struct{}{},
+		Entries: entries,
+	}}
+}
+
+func (r *harRecorder) requestWillBeSent(session proto.TargetSessionID, e *proto.NetworkRequestWillBeSent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active || !r.sessions[session] || e.Request == nil {
+		return
+	}
+
+	key := harKey{session, e.RequestID}
+	// Редирект приходит тем же ID: ответ 3xx завершает предыдущую запись
+	if prev, ok := r.calls[key]; ok && e.RedirectResponse != nil {
+		r.applyResponse(key, prev, e.RedirectResponse, e.Timestamp)
+		prev.entry.Response.RedirectURL = e.Request.URL
+		r.finish(prev, e.Timestamp, 0)
+	}
+
+	entry := &harEntry{
+		StartedDateTime: e.WallTime.Time().UTC().Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      e.Request.Method,
+			URL:         e.Request.URL,
+			Cookies:     []harCookie{},
+			QueryString: harQuery(e.Request.URL),
+			HeadersSize: -1,
+		},
+		Response: harResponse{
+			Cookies:     []harCookie{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		ResourceType: strings.ToLower(string(e.Type)),
+	}
+	c := &harCall{entry: entry, started: e.Timestamp}
+	headers := e.Request.Headers
+	if extra, ok := r.extraReq[key]; ok {
+		headers = mergeHeaders(headers, extra)
+		delete(r.extraReq, key)
+	}
+	r.setRequestHeaders(c, headers)
+	entry.Request.BodySize = len(e.Request.PostData)
+	if r.cfg.Bodies && e.Request.PostData != "" {
+		entry.Request.PostData = &harPostData{
+			MimeType: headerValue(e.Request.Headers, "Content-Type"),
+			Text:     e.Request.PostData,
+		}
+	}
+
+	r.entries = append(r.entries, entry)
+	r.calls[key] = c
+}
+
+func (r *harRecorder) requestExtraInfo(session proto.TargetSessionID, e *proto.NetworkRequestWillBeSentExtraInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active || !r.sessions[session] {
+		return
+	}
+
+	key := harKey{session, e.RequestID}
+	// Заголовки ExtraInfo — то, что ушло в сеть, включая Cookie
+	if c, ok := r.calls[key]; ok {
+		r.setRequestHeaders(c, mergeHeaders(c.reqHeaders, e.Headers))
+	} else {
+		r.extraReq[key] = e.Headers
+	}
+}
+
+func (r *harRecorder) responseReceived(session proto.TargetSessionID, e *proto.NetworkResponseReceived) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active || e.Response == nil {
+		return
+	}
+
+	key := harKey{session, e.RequestID}
+	c, ok := r.calls[key]
+	if !ok {
+		return
+	}
+	r.applyResponse(key, c, e.Response, e.Timestamp)
+}
+
+func (r *harRecorder) responseExtraInfo(session proto.TargetSessionID, e *proto.NetworkResponseReceivedExtraInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active || !r.sessions[session] {
+		return
+	}
+
+	key := harKey{session, e.RequestID}
+	// Set-Cookie видно только в ExtraInfo. Оно может прийти и до ответа — тогда ждёт его.
+	if c, ok := r.calls[key]; ok && c.entry.Response.Status != 0 {
+		r.setResponseHeaders(c, mergeHeaders(c.respHeaders, e.Headers))
+	} else {
+		r.extraResp[key] = e.Headers
+	}
+}
+
+func (r *harRecorder) loadingFinished(session proto.TargetSessionID, e *proto.NetworkLoadingFinished) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active {
+		return
+	}
+
+	key := harKey{session, e.RequestID}
+	c, ok := r.calls[key]
+	if !ok {
+		return
+	}
+	r.finish(c, e.Timestamp, int(e.EncodedDataLength))
+	delete(r.calls, key)
+
+	if r.cfg.Bodies && r.fetchBody != nil {
+		r.bodies.Add(1)
+		go r.loadBody(r.bodies, c.entry, key, r.gen)
+	}
+}
+
+func (r *harRecorder) loadingFailed(session proto.TargetSessionID, e *proto.NetworkLoadingFailed) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active {
+		return
+	}
+
+	key := harKey{session, e.RequestID}
+	c, ok := r.calls[key]
+	if !ok {
+		return
+	}
+	c.entry.Error = e.ErrorText
+	if e.BlockedReason != "" {
+		c.entry.Error += " (" + string(e.BlockedReason) + ")"
+	}
+	r.finish(c, e.Timestamp, 0)
+	delete(r.calls, key)
+}
+
+// loadBody дописывает тело ответа в запись, если оно не больше MaxBodySize.
+// Тело, пришедшее после конца своей записи, отбрасывается.
+func (r *harRecorder) loadBody(bodies *sync.WaitGroup, entry *harEntry, key harKey, gen int) {
+	defer bodies.Done()
+
+	body, err := r.fetchBody(key.session, key.id)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gen != gen {
+		return
+	}
+	content := &entry.Response.Content
+	switch {
+	case err != nil:
+		// Тела нет у редиректов, ответов без содержимого и закрытых вкладок
+		return
+	case body.Base64Encoded:
+		size := base64.StdEncoding.DecodedLen(len(body.Body))
+		content.Size = size
+		if size > r.cfg.MaxBodySize {
+			content.Comment = "body is larger than the HAR body limit"
+			return
+		}
+		content.Text = body.Body
+		content.Encoding = "base64"
+	default:
+		content.Size = len(body.Body)
+		if len(body.Body) > r.cfg.MaxBodySize {
+			content.Comment = "body is larger than the HAR body limit"
+			return
+		}
+		content.Text = body.Body
+	}
+}
+
+func (r *harRecorder) applyResponse(key harKey, c *harCall, resp *proto.NetworkResponse, at proto.MonotonicTime) {
+	e := c.entry
+	e.Response.Status = resp.Status
+	e.Response.StatusText = resp.StatusText
+	if e.Response.StatusText == "" {
+		e.Response.StatusText = http.StatusText(resp.Status)
+	}
+	version := harHTTPVersion(resp.Protocol)
+	e.Response.HTTPVersion = version
+	e.Request.HTTPVersion = version
+	e.Response.Content.MimeType = resp.MIMEType
+	e.ServerIPAddress = strings.Trim(resp.RemoteIPAddress, "[]")
+
+	headers := resp.Headers
+	if extra, ok := r.extraResp[key]; ok {
+		headers = mergeHeaders(headers, extra)
+		delete(r.extraResp, key)
+	}
+	r.setResponseHeaders(c, headers)
+
+	c.response = at
+	c.timing = resp.Timing
+}
+
+// finish рассчитывает фазы запроса по отметкам времени CDP, at — конец загрузки
+func (r *harRecorder) finish(c *harCall, at proto.MonotonicTime, encodedSize int) {
+	c.done = true
+	e := c.entry
+	if encodedSize > 0 {
+		e.Response.BodySize = encodedSize
+		if e.Response.Content.Size == 0 {
+			e.Response.Content.Size = encodedSize
+		}
+	}
+	e.Timings = computeTimings(c.started, c.response, at, c.timing)
+	e.Time = totalTime(e.Timings)
+}
+
+func (r *harRecorder) setRequestHeaders(c *harCall, headers proto.NetworkHeaders) {
+	c.reqHeaders = headers
+	e := c.entry
+	e.Request.Headers = harHeaders(headers, !r.cfg.KeepSecrets)
+	e.Request.Cookies = requestCookies(headerValue(headers, "Cookie"), !r.cfg.KeepSecrets)
+}
+
+func (r *harRecorder) setResponseHeaders(c *harCall, headers proto.NetworkHeaders) {
+	c.respHeaders = headers
+	e := c.entry
+	e.Response.Headers = harHeaders(headers, !r.cfg.KeepSecrets)
+	e.Response.Cookies = responseCookies(headerValue(headers, "Set-Cookie"), !r.cfg.KeepSecrets)
+	if loc := headerValue(headers, "Location"); loc != "" {
+		e.Response.RedirectURL = loc
+	}
+}
+
+// computeTimings переводит отметки CDP (секунды) в фазы HAR (мс). Без подробного тайминга
+// (кэш, data:, ошибка) вся задержка до ответа считается ожиданием.
+func computeTimings(started, response, finished proto.MonotonicTime, t *proto.NetworkResourceTiming) harTimings {
+	ms := func(d proto.MonotonicTime) float64 {
+		if d < 0 {
+			return 0
+		}
+		return float64(d) * 1000
+	}
+	if response == 0 {
+		response = finished
+	}
+
+	if t == nil {
+		return harTimings{
+			Blocked: -1, DNS: -1, Connect: -1, SSL: -1,
+			Wait:    ms(response - started),
+			Receive: ms(finished - response),
+		}
+	}
+
+	phase := func(start, end float64) float64 {
+		if start < 0 || end < start {
+			return -1
+		}
+		return end - start
+	}
+	res := harTimings{
+		Blocked: -1,
+		DNS:     phase(t.DNSStart, t.DNSEnd),
+		Connect: phase(t.ConnectStart, t.ConnectEnd),
+		SSL:     phase(t.SslStart, t.SslEnd),
+		Send:    max(t.SendEnd-t.SendStart, 0),
+		Wait:    max(t.ReceiveHeadersEnd-t.SendEnd, 0),
+	}
+	// Очередь до первой сетевой фазы и время от запроса до старта тайминга браузера
+	queued := ms(proto.MonotonicTime(t.RequestTime) - started)
+	for _, first := range []float64{t.DNSStart, t.ConnectStart, t.SendStart} {
+		if first >= 0 {
+			res.Blocked = queued + first
+			break
+		}
+	}
+	headersAt := proto.MonotonicTime(t.RequestTime) + proto.MonotonicTime(t.ReceiveHeadersEnd/1000)
+	res.Receive = ms(finished - headersAt)
+	return res
+}
+
+// totalTime — полное время запроса: сумма фаз без SSL, который входит в connect
+func totalTime(t harTimings) float64 {
+	total := 0.0
+	for _, v := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+// harHeaders переводит заголовки CDP в список HAR, отсортированный по имени.
+// Несколько значений одного заголовка CDP склеивает через перевод строки.
+func harHeaders(headers proto.NetworkHeaders, redact bool) []harNameValue {
+	list := []harNameValue{}
+	for name, v := range headers {
+		for _, value := range strings.Split(v.Str(), "\n") {
+			if redact && harSecretHeaders[strings.ToLower(name)] {
+				value = harRedacted
+			}
+			list = append(list, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+func requestCookies(header string, redact bool) []harCookie {
+	cookies := []harCookie{}
+	if header == "" {
+		return cookies
+	}
+	parsed, err := http.ParseCookie(header)
+	if err != nil {
+		return cookies
+	}
+	for _, c := range parsed {
+		cookie := harCookie{Name: c.Name, Value: c.Value}
+		if redact {
+			cookie.Value = harRedacted
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+func responseCookies(header string, redact bool) []harCookie {
+	cookies := []harCookie{}
+	for _, line := range strings.Split(header, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+		cookie := harCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if redact {
+			cookie.Value = harRedacted
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+func harQuery(rawURL string) []harNameValue {
+	list := []harNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return list
+	}
+	for name, values := range u.Query() {
+		for _, v := range values {
+			list = append(list, harNameValue{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// harHTTPVersion переводит протокол CDP (h2, http/1.1) в запись HAR
+func harHTTPVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "":
+		return ""
+	case "h2":
+		return "HTTP/2"
+	case "h3", "h3-29":
+		return "HTTP/3"
+	default:
+		return strings.ToUpper(protocol)
+	}
+}
+
+// headerValue ищет заголовок без учёта регистра имени
+func headerValue(headers proto.NetworkHeaders, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v.Str()
+		}
+	}
+	return ""
+}
+
+// mergeHeaders дополняет заголовки значениями extra; одноимённые в другом регистре заменяются
+func mergeHeaders(base, extra proto.NetworkHeaders) proto.NetworkHeaders {
+	merged := proto.NetworkHeaders{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		for old := range merged {
+			if strings.EqualFold(old, k) {
+				delete(merged, old)
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// startHAR готовит запись трафика, если она включена в конфигурации: подписывается на события
+// Network браузера до его закрытия. Сами вкладки подключает trackNetwork.
+func (m *Manager) startHAR() {
+	if m.config.HAR == nil {
+		return
+	}
+	rec := newHARRecorder(*m.config.HAR)
+	rec.fetchBody = func(session proto.TargetSessionID, id proto.NetworkRequestID) (*proto.NetworkGetResponseBodyResult, error) {
+		ctx, cancel := context.WithTimeout(m.browser.GetContext(), harBodyWait)
+		defer cancel()
+		raw, err := m.browser.Call(ctx, string(session), proto.NetworkGetResponseBody{}.ProtoReq(), proto.NetworkGetResponseBody{RequestID: id})
+		if err != nil {
+			return nil, err
+		}
+		var res proto.NetworkGetResponseBodyResult
+		if err := json.Unmarshal(raw, &res); err != nil {
+			return nil, err
+		}
+		return &res, nil
+	}
+	m.har = rec
+
+	// События Network приходят от всех вкладок браузера, recorder берёт только отслеживаемые
+	go m.browser.EachEvent(func(e *proto.NetworkRequestWillBeSent, s proto.TargetSessionID) {
+		rec.requestWillBeSent(s, e)
+	}, func(e *proto.NetworkRequestWillBeSentExtraInfo, s proto.TargetSessionID) {
+		rec.requestExtraInfo(s, e)
+	}, func(e *proto.NetworkResponseReceived, s proto.TargetSessionID) {
+		rec.responseReceived(s, e)
+	}, func(e *proto.NetworkResponseReceivedExtraInfo, s proto.TargetSessionID) {
+		rec.responseExtraInfo(s, e)
+	}, func(e *proto.NetworkLoadingFinished, s proto.TargetSessionID) {
+		rec.loadingFinished(s, e)
+	}, func(e *proto.NetworkLoadingFailed, s proto.TargetSessionID) {
+		rec.loadingFailed(s, e)
+	})()
+}
+
+// trackNetwork включает события Network вкладки для записи HAR. Включённый домен
+// учитывается rod, поэтому ожидание settle не выключит его после действия.
+func (m *Manager) trackNetwork(page *rod.Page) {
+	if m.har == nil {
+		return
+	}
+	m.har.track(page.SessionID)
+	if err := (proto.NetworkEnable{}).Call(page); err != nil && m.config.Debug {
+		m.log.Debug("Failed to enable network events for HAR", "error", err.Error())
+	}
+}
+
+// StartHAR начинает запись сетевого трафика всех вкладок, если она включена в конфигурации
+// (BrowserConfig.HAR). Прежняя незаконченная запись отбрасывается.
+func (m *Manager) StartHAR() {
+	if m.har != nil {
+		m.har.start()
+	}
+}
+
+// StopHAR завершает запись и возвращает HAR 1.2 в JSON; nil — запись не включена
+func (m *Manager) StopHAR() ([]byte, error) {
+	if m.har == nil {
+		return nil, nil
+	}
+	data, err := json.MarshalIndent(m.har.stop(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode HAR: %w", err)
+	}
+	return data, nil
+}
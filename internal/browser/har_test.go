@@ -0,0 +1,290 @@
+package browser
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+	"github.com/ysmood/gson"
+)
+
+func headers(kv ...string) proto.NetworkHeaders {
+	h := proto.NetworkHeaders{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		h[kv[i]] = gson.New(kv[i+1])
+	}
+	return h
+}
+
+func findHeader(list []harNameValue, name string) []string {
+	var values []string
+	for _, h := range list {
+		if h.Name == name {
+			values = append(values, h.Value)
+		}
+	}
+	return values
+}
+
+// recordPage прогоняет через recorder запрос страницы с редиректом, cookies и телом ответа
+func recordPage(t *testing.T, cfg types.HARConfig) *harFile {
+	t.Helper()
+
+	r := newHARRecorder(cfg)
+	r.fetchBody = func(session proto.TargetSessionID, id proto.NetworkRequestID) (*proto.NetworkGetResponseBodyResult, error) {
+		if id == "2" {
+			return nil, errors.New("no body")
+		}
+		return &proto.NetworkGetResponseBodyResult{Body: "<html>ok</html>"}, nil
+	}
+	r.track("tab")
+	r.start()
+
+	r.requestWillBeSent("tab", &proto.NetworkRequestWillBeSent{
+		RequestID: "1",
+		Request: &proto.NetworkRequest{
+			URL:      "http://example.com/login?next=%2Fhome",
+			Method:   "POST",
+			Headers:  headers("Content-Type", "application/x-www-form-urlencoded", "Authorization", "Bearer secret"),
+			PostData: "user=ann",
+		},
+		Timestamp: 100,
+		WallTime:  1700000000,
+		Type:      proto.NetworkResourceTypeDocument,
+	})
+	r.requestExtraInfo("tab", &proto.NetworkRequestWillBeSentExtraInfo{
+		RequestID: "1",
+		Headers:   headers("Cookie", "sid=abc; theme=dark"),
+	})
+	// Set-Cookie редиректа приходит раньше самого редиректа
+	r.responseExtraInfo("tab", &proto.NetworkResponseReceivedExtraInfo{
+		RequestID: "1",
+		Headers:   headers("Set-Cookie", "sid=new; Path=/; HttpOnly\ntrack=1"),
+	})
+	r.requestWillBeSent("tab", &proto.NetworkRequestWillBeSent{
+		RequestID: "1",
+		Request:   &proto.NetworkRequest{URL: "https://example.com/home", Method: "GET", Headers: headers()},
+		Timestamp: 100.2,
+		WallTime:  1700000000.2,
+		Type:      proto.NetworkResourceTypeDocument,
+		RedirectResponse: &proto.NetworkResponse{
+			Status:   302,
+			Headers:  headers("Location", "/home"),
+			Protocol: "http/1.1",
+		},
+	})
+	r.responseReceived("tab", &proto.NetworkResponseReceived{
+		RequestID: "1",
+		Timestamp: 100.5,
+		Response: &proto.NetworkResponse{
+			Status:     200,
+			StatusText: "OK",
+			Headers:    headers("Content-Type", "text/html"),
+			MIMEType:   "text/html",
+			Protocol:   "h2",
+			Timing: &proto.NetworkResourceTiming{
+				RequestTime: 100.25, DNSStart: -1, DNSEnd: -1, ConnectStart: -1, ConnectEnd: -1,
+				SslStart: -1, SslEnd: -1, SendStart: 10, SendEnd: 12, ReceiveHeadersEnd: 212,
+			},
+		},
+	})
+	r.loadingFinished("tab", &proto.NetworkLoadingFinished{RequestID: "1", Timestamp: 100.6, EncodedDataLength: 15})
+
+	// Запрос чужой вкладки и незавершённый запрос
+	r.requestWillBeSent("other", &proto.NetworkRequestWillBeSent{
+		RequestID: "9",
+		Request:   &proto.NetworkRequest{URL: "https://other.example/", Method: "GET"},
+	})
+	r.requestWillBeSent("tab", &proto.NetworkRequestWillBeSent{
+		RequestID: "3",
+		Request:   &proto.NetworkRequest{URL: "https://example.com/poll", Method: "GET"},
+		Timestamp: 101,
+	})
+	r.requestWillBeSent("tab", &proto.NetworkRequestWillBeSent{
+		RequestID: "4",
+		Request:   &proto.NetworkRequest{URL: "https://ads.example/x.js", Method: "GET"},
+		Timestamp: 101,
+	})
+	r.loadingFailed("tab", &proto.NetworkLoadingFailed{
+		RequestID: "4", Timestamp: 101.05, ErrorText: "net::ERR_BLOCKED_BY_CLIENT",
+	})
+
+	har := r.stop()
+
+	// Файл должен быть корректным JSON
+	if _, err := json.Marshal(har); err != nil {
+		t.Fatal(err)
+	}
+	return har
+}
+
+func TestHARRecorder_Entries(t *testing.T) {
+	har := recordPage(t, types.HARConfig{})
+	entries := har.Log.Entries
+
+	if har.Log.Version != "1.2" {
+		t.Errorf("version = %q", har.Log.Version)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("entries = %d, want 4 (foreign tab excluded)", len(entries))
+	}
+
+	redirect, page, poll, failed := entries[0], entries[1], entries[2], entries[3]
+
+	if redirect.Response.Status != 302 || redirect.Response.RedirectURL != "https://example.com/home" {
+		t.Errorf("redirect response = %d %q", redirect.Response.Status, redirect.Response.RedirectURL)
+	}
+	if redirect.Request.Method != "POST" || redirect.StartedDateTime != "2023-11-14T22:13:20Z" {
+		t.Errorf("redirect request = %s at %s", redirect.Request.Method, redirect.StartedDateTime)
+	}
+	if len(redirect.Request.QueryString) != 1 || redirect.Request.QueryString[0].Value != "/home" {
+		t.Errorf("query = %+v", redirect.Request.QueryString)
+	}
+	if math.Abs(redirect.Time-200) > 0.001 {
+		t.Errorf("redirect time = %v, want 200", redirect.Time)
+	}
+	// Тело запроса сохраняется только с Bodies
+	if redirect.Request.PostData != nil {
+		t.Error("post data recorded without Bodies")
+	}
+
+	if page.Response.Status != 200 || page.Response.HTTPVersion != "HTTP/2" || page.ResourceType != "document" {
+		t.Errorf("page = %d %s %s", page.Response.Status, page.Response.HTTPVersion, page.ResourceType)
+	}
+	tm := page.Timings
+	if tm.DNS != -1 || tm.Connect != -1 || tm.Send != 2 || tm.Wait != 200 {
+		t.Errorf("timings = %+v", tm)
+	}
+	// 50 мс в очереди до старта тайминга и 10 до начала отправки
+	if math.Abs(tm.Blocked-60) > 0.001 || math.Abs(tm.Receive-138) > 0.001 {
+		t.Errorf("blocked/receive = %v/%v, want 60/138", tm.Blocked, tm.Receive)
+	}
+	if page.Response.Content.Text != "" {
+		t.Error("body recorded without Bodies")
+	}
+
+	if poll.Error == "" || poll.Response.Status != 0 {
+		t.Errorf("unfinished request = %+v", poll)
+	}
+	if failed.Error != "net::ERR_BLOCKED_BY_CLIENT" || math.Abs(failed.Timings.Wait-50) > 0.001 {
+		t.Errorf("failed request = %q wait %v", failed.Error, failed.Timings.Wait)
+	}
+}
+
+func TestHARRecorder_Redaction(t *testing.T) {
+	har := recordPage(t, types.HARConfig{})
+	redirect := har.Log.Entries[0]
+
+	if v := findHeader(redirect.Request.Headers, "Authorization"); len(v) != 1 || v[0] != harRedacted {
+		t.Errorf("Authorization = %v", v)
+	}
+	if v := findHeader(redirect.Request.Headers, "Cookie"); len(v) != 1 || v[0] != harRedacted {
+		t.Errorf("Cookie = %v", v)
+	}
+	if len(redirect.Request.Cookies) != 2 || redirect.Request.Cookies[0].Name != "sid" || redirect.Request.Cookies[0].Value != harRedacted {
+		t.Errorf("request cookies = %+v", redirect.Request.Cookies)
+	}
+	if v := findHeader(redirect.Response.Headers, "Set-Cookie"); len(v) != 2 || v[0] != harRedacted {
+		t.Errorf("Set-Cookie = %v", v)
+	}
+	if c := redirect.Response.Cookies; len(c) != 2 || !c[0].HTTPOnly || c[0].Value != harRedacted {
+		t.Errorf("response cookies = %+v", c)
+	}
+	if v := findHeader(redirect.Request.Headers, "Content-Type"); len(v) != 1 || v[0] != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %v", v)
+	}
+
+	har = recordPage(t, types.HARConfig{KeepSecrets: true, Bodies: true})
+	redirect, page := har.Log.Entries[0], har.Log.Entries[1]
+
+	if v := findHeader(redirect.Request.Headers, "Authorization"); len(v) != 1 || v[0] != "Bearer secret" {
+		t.Errorf("Authorization with KeepSecrets = %v", v)
+	}
+	if c := redirect.Request.Cookies; len(c) != 2 || c[0].Value != "abc" {
+		t.Errorf("request cookies with KeepSecrets = %+v", c)
+	}
+	if redirect.Request.PostData == nil || redirect.Request.PostData.Text != "user=ann" {
+		t.Errorf("post data = %+v", redirect.Request.PostData)
+	}
+	if page.Response.Content.Text != "<html>ok</html>" || page.Response.Content.Size != 15 {
+		t.Errorf("content = %+v", page.Response.Content)
+	}
+}
+
+func TestHARRecorder_BodyLimit(t *testing.T) {
+	r := newHARRecorder(types.HARConfig{Bodies: true, MaxBodySize: 4})
+	r.fetchBody = func(proto.TargetSessionID, proto.NetworkRequestID) (*proto.NetworkGetResponseBodyResult, error) {
+		return &proto.NetworkGetResponseBodyResult{Body: "aGVsbG8=", Base64Encoded: true}, nil
+	}
+	r.track("tab")
+	r.start()
+	r.requestWillBeSent("tab", &proto.NetworkRequestWillBeSent{
+		RequestID: "1",
+		Request:   &proto.NetworkRequest{URL: "https://example.com/a.bin", Method: "GET"},
+	})
+	r.loadingFinished("tab", &proto.NetworkLoadingFinished{RequestID: "1"})
+
+	content := r.stop().Log.Entries[0].Response.Content
+	if content.Text != "" || content.Comment == "" || content.Size != 6 {
+		t.Errorf("content over limit = %+v", content)
+	}
+}
+
+func TestHARRecorder_Inactive(t *testing.T) {
+	r := newHARRecorder(types.HARConfig{})
+	r.track("tab")
+	r.requestWillBeSent("tab", &proto.NetworkRequestWillBeSent{
+		RequestID: "1",
+		Request:   &proto.NetworkRequest{URL: "https://example.com/", Method: "GET"},
+	})
+
+	r.start()
+	if n := len(r.stop().Log.Entries); n != 0 {
+		t.Errorf("entries before start = %d", n)
+	}
+}
+
+func TestHARRecorder_LateBody(t *testing.T) {
+	defer func(wait time.Duration) { harBodyWait = wait }(harBodyWait)
+	harBodyWait = 10 * time.Millisecond
+
+	release := make(chan struct{})
+	r := newHARRecorder(types.HARConfig{Bodies: true})
+	r.fetchBody = func(_ proto.TargetSessionID, id proto.NetworkRequestID) (*proto.NetworkGetResponseBodyResult, error) {
+		if id == "slow" {
+			<-release
+			return &proto.NetworkGetResponseBodyResult{Body: "late"}, nil
+		}
+		return &proto.NetworkGetResponseBodyResult{Body: "fresh"}, nil
+	}
+	r.track("tab")
+	load := func(id proto.NetworkRequestID) {
+		r.requestWillBeSent("tab", &proto.NetworkRequestWillBeSent{
+			RequestID: id,
+			Request:   &proto.NetworkRequest{URL: "https://example.com/" + string(id), Method: "GET"},
+		})
+		r.loadingFinished("tab", &proto.NetworkLoadingFinished{RequestID: id})
+	}
+
+	// Тело первой записи не успевает прийти до её конца
+	r.start()
+	load("slow")
+	if text := r.stop().Log.Entries[0].Response.Content.Text; text != "" {
+		t.Errorf("body of the stopped run = %q", text)
+	}
+
+	r.start()
+	load("fast")
+	close(release)
+	har := r.stop()
+	if n := len(har.Log.Entries); n != 1 {
+		t.Fatalf("entries of the second run = %d", n)
+	}
+	if text := har.Log.Entries[0].Response.Content.Text; text != "fresh" {
+		t.Errorf("body of the second run = %q", text)
+	}
+}
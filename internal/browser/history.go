@@ -0,0 +1,128 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+const (
+	// historyWait — сколько ждать, пока переход по истории применится
+	historyWait = 5 * time.Second
+	// historyPoll — интервал проверки текущей записи истории
+	historyPoll = 100 * time.Millisecond
+)
+
+// GoBack переходит на предыдущую страницу истории активной вкладки и ждёт её загрузки
+func (m *Manager) GoBack(ctx context.Context) error {
+	return m.moveInHistory(ctx, -1)
+}
+
+// GoForward переходит на следующую страницу истории активной вкладки и ждёт её загрузки
+func (m *Manager) GoForward(ctx context.Context) error {
+	return m.moveInHistory(ctx, 1)
+}
+
+// Reload перезагружает активную вкладку и ждёт загрузки
+func (m *Manager) Reload(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Reloading page")
+	}
+
+	w := m.watchSettle()
+	_, err := untilDialog(w, func(context.Context) (struct{}, error) {
+		return struct{}{}, m.activePage().Context(ctx).Reload()
+	})
+	if err != nil {
+		w.cancel()
+		return fmt.Errorf("reload failed: %w", err)
+	}
+	m.settle(ctx, w)
+
+	return nil
+}
+
+// History возвращает записи истории активной вкладки до и после текущей
+func (m *Manager) History() (types.HistoryInfo, error) {
+	res, err := m.activePage().GetNavigationHistory()
+	if err != nil {
+		return types.HistoryInfo{}, fmt.Errorf("get navigation history: %w", err)
+	}
+
+	var info types.HistoryInfo
+	for i, entry := range res.Entries {
+		e := types.HistoryEntry{Title: entry.Title, URL: entry.URL}
+		switch {
+		case i < res.CurrentIndex:
+			info.Back = append(info.Back, e)
+		case i > res.CurrentIndex:
+			info.Forward = append(info.Forward, e)
+		}
+	}
+	return info, nil
+}
+
+// moveInHistory сдвигается по истории на delta записей. history.back() асинхронный,
+// поэтому сначала ждём смены текущей записи, потом пока страница успокоится.
+func (m *Manager) moveInHistory(ctx context.Context, delta int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	page := m.activePage()
+	before, err := page.GetNavigationHistory()
+	if err != nil {
+		return fmt.Errorf("get navigation history: %w", err)
+	}
+
+	target := before.CurrentIndex + delta
+	if target < 0 {
+		return fmt.Errorf("no previous page in the history of this tab")
+	}
+	if target >= len(before.Entries) {
+		return fmt.Errorf("no next page in the history of this tab")
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Moving in history", "delta", delta, "url", before.Entries[target].URL)
+	}
+
+	w := m.watchSettle()
+	_, err = untilDialog(w, func(context.Context) (struct{}, error) {
+		if delta < 0 {
+			return struct{}{}, page.NavigateBack()
+		}
+		return struct{}{}, page.NavigateForward()
+	})
+	if err != nil {
+		w.cancel()
+		return fmt.Errorf("history navigation failed: %w", err)
+	}
+
+	// Диалог beforeunload держит вкладку на месте до ответа
+	deadline := time.Now().Add(historyWait)
+	for !w.dialogOpened() && time.Now().Before(deadline) {
+		if cur, err := page.GetNavigationHistory(); err == nil && cur.CurrentIndex == target {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			w.cancel()
+			return ctx.Err()
+		case <-time.After(historyPoll):
+		}
+	}
+
+	m.settle(ctx, w)
+	return nil
+}
@@ -0,0 +1,110 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// pingTimeout — сколько ждать ответа браузера при проверке здоровья
+const pingTimeout = 5 * time.Second
+
+// NewIncognito создаёт менеджер в новом инкогнито-контексте того же процесса Chrome.
+// У контекста свои cookies, хранилища, кэш и вкладки; Close удаляет контекст со всеми данными,
+// не закрывая браузер.
+func (m *Manager) NewIncognito() (*Manager, error) {
+	if m.browser == nil {
+		return nil, fmt.Errorf("browser is not launched")
+	}
+
+	b, err := m.browser.Incognito()
+	if err != nil {
+		return nil, fmt.Errorf("create incognito context: %w", err)
+	}
+
+	// Свой контекст у подписок на события: после Close контекста они не должны висеть
+	// в общем браузере до его закрытия
+	ctx, stop := context.WithCancel(m.browser.GetContext())
+	config := *m.config
+	config.UserDataDir = ""
+	child := NewManager(&config, m.log)
+	child.browser = b.Context(ctx)
+	child.stopEvents = stop
+
+	go child.watchDownloads()
+	go child.watchDialogs()
+	child.startHAR()
+
+	page, err := child.browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		_ = child.Close()
+		return nil, fmt.Errorf("open incognito tab: %w", err)
+	}
+	child.page = page
+	child.tabs.add(page, 0, false)
+	child.setupTab(page)
+	if err := child.SetEmulation(config.Emulation); err != nil {
+		_ = child.Close()
+		return nil, err
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Incognito context created", "context", b.BrowserContextID)
+	}
+	return child, nil
+}
+
+// Ping проверяет, что браузер отвечает и активная вкладка жива. Ошибка значит, что процесс
+// Chrome упал или вкладка закрыта крашем рендерера, и менеджер нужно пересоздать.
+func (m *Manager) Ping(ctx context.Context) error {
+	if m.browser == nil {
+		return fmt.Errorf("browser is not launched")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	b := m.browser.Context(ctx)
+	if _, err := (proto.BrowserGetVersion{}).Call(b); err != nil {
+		return fmt.Errorf("browser not responding: %w", err)
+	}
+	info, err := proto.TargetGetTargetInfo{TargetID: m.activePage().TargetID}.Call(b)
+	if err != nil {
+		return fmt.Errorf("active tab is gone: %w", err)
+	}
+	if info.TargetInfo.Type != proto.TargetTargetInfoTypePage {
+		return fmt.Errorf("active tab is gone")
+	}
+	return nil
+}
+
+// ownsFrame проверяет, что фрейм принадлежит одной из вкладок менеджера
+func (m *Manager) ownsFrame(id proto.PageFrameID) bool {
+	for _, page := range m.tabPages() {
+		if page.FrameID == id {
+			return true
+		}
+		tree, err := proto.PageGetFrameTree{}.Call(page)
+		if err == nil && frameTreeHas(tree.FrameTree, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func frameTreeHas(tree *proto.PageFrameTree, id proto.PageFrameID) bool {
+	if tree == nil {
+		return false
+	}
+	if tree.Frame != nil && tree.Frame.ID == id {
+		return true
+	}
+	for _, child := range tree.ChildFrames {
+		if frameTreeHas(child, id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,339 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// hoverDelay — пауза между наведением и кликом, чтобы сработали hover-меню и обработчики mouseover
+const hoverDelay = 100 * time.Millisecond
+
+// inputTargetJS прокручивает элемент в центр видимой области и проверяет, что нативный ввод
+// в его центр попадёт именно в него: у элемента есть размер, центр внутри viewport
+// и не перекрыт другим элементом (оверлеем, баннером cookies, модальным окном).
+const inputTargetJS = `(id) => {
+	if (!window._ai_elements || !(id in window._ai_elements)) {
+		throw new Error("Element ID " + id + " not found. Call extract_page to get current IDs.");
+	}
+	const el = window._ai_resolve(id);
+	if (!el) {
+		throw new Error("Element ID " + id + " is no longer on the page. Call extract_page again.");
+	}
+
+	el.scrollIntoView({block: "center", inline: "center", behavior: "instant"});
+
+	const describe = (node) => {
+		let s = node.tagName.toLowerCase();
+		if (node.id) {
+			s += "#" + node.id;
+		} else if (typeof node.className === "string" && node.className.trim()) {
+			s += "." + node.className.trim().split(/\s+/)[0];
+		}
+		const text = (node.innerText || "").trim().replace(/\s+/g, " ").slice(0, 40);
+		return text ? s + " \"" + text + "\"" : s;
+	};
+	const inside = (node) => {
+		for (let n = node; n; n = n.parentNode || n.host) {
+			if (n === el) return true;
+		}
+		return false;
+	};
+
+	return new Promise(resolve => setTimeout(() => {
+		const r = el.getBoundingClientRect();
+		if (r.width < 1 || r.height < 1) {
+			resolve({ok: false, reason: "element has no size (hidden)"});
+			return;
+		}
+		const x = r.left + r.width / 2;
+		const y = r.top + r.height / 2;
+		if (x < 0 || y < 0 || x > window.innerWidth || y > window.innerHeight) {
+			resolve({ok: false, reason: "element center is outside the viewport"});
+			return;
+		}
+
+		let hit = document.elementFromPoint(x, y);
+		while (hit && hit.shadowRoot) {
+			const inner = hit.shadowRoot.elementFromPoint(x, y);
+			if (!inner || inner === hit) break;
+			hit = inner;
+		}
+		if (!hit) {
+			resolve({ok: false, reason: "nothing is rendered at the element center"});
+			return;
+		}
+		const byLabel = Array.from(el.labels || []).some(l => l.contains(hit));
+		if (!inside(hit) && !byLabel) {
+			resolve({ok: false, reason: "element is covered by <" + describe(hit) + ">"});
+			return;
+		}
+		resolve({ok: true, x: x, y: y});
+	}, 50));
+}`
+
+// clickJS — запасной клик через el.click()
+const clickJS = `(id) => {
+	const el = window._ai_resolve(id);
+	if (!el) {
+		throw new Error("Element ID " + id + " is no longer on the page. Call extract_page again.");
+	}
+	el.scrollIntoView({block: "center", inline: "center"});
+	el.click();
+	return true;
+}`
+
+// hoverJS — запасное наведение синтетическими событиями мыши
+const hoverJS = `(id) => {
+	const el = window._ai_resolve(id);
+	if (!el) {
+		throw new Error("Element ID " + id + " is no longer on the page. Call extract_page again.");
+	}
+	el.scrollIntoView({block: "center", inline: "center"});
+	for (const type of ["pointerover", "pointerenter", "mouseover", "mouseenter", "mousemove"]) {
+		el.dispatchEvent(new MouseEvent(type, {bubbles: type !== "mouseenter" && type !== "pointerenter", view: window}));
+	}
+	return true;
+}`
+
+// selectContentJS фокусирует поле и выделяет его содержимое, чтобы вставка текста его заменила
+const selectContentJS = `(id) => {
+	const el = window._ai_resolve(id);
+	if (!el) {
+		throw new Error("Element ID " + id + " is no longer on the page. Call extract_page again.");
+	}
+	el.focus();
+	if (typeof el.select === "function") {
+		el.select();
+	} else if (el.isContentEditable) {
+		const range = document.createRange();
+		range.selectNodeContents(el);
+		const sel = window.getSelection();
+		sel.removeAllRanges();
+		sel.addRange(range);
+	}
+	return true;
+}`
+
+// fieldValueJS возвращает текущее значение поля или текст contenteditable
+const fieldValueJS = `(id) => {
+	const el = window._ai_resolve(id);
+	if (!el) return "";
+	return "value" in el ? String(el.value) : (el.innerText || "");
+}`
+
+// setValueJS — запасной ввод: значение ставится через сеттер прототипа, чтобы его заметили
+// контролируемые поля React, затем отправляются события input/change
+const setValueJS = `(args) => {
+	const el = window._ai_resolve(args.id);
+	if (!el) {
+		throw new Error("Element ID " + args.id + " is no longer on the page. Call extract_page again.");
+	}
+	el.scrollIntoView({block: "center"});
+	el.focus();
+	if (el.isContentEditable) {
+		el.textContent = args.text;
+	} else {
+		const proto = el instanceof HTMLTextAreaElement ? HTMLTextAreaElement.prototype
+			: el instanceof HTMLSelectElement ? HTMLSelectElement.prototype
+			: HTMLInputElement.prototype;
+		const setter = Object.getOwnPropertyDescriptor(proto, "value").set;
+		setter.call(el, args.text);
+	}
+	el.dispatchEvent(new Event('input', { bubbles: true }));
+	el.dispatchEvent(new Event('change', { bubbles: true }));
+	el.dispatchEvent(new KeyboardEvent('keyup', { bubbles: true }));
+	return true;
+}`
+
+// inputPoint проверяет элемент для нативного ввода и возвращает его центр в координатах вкладки.
+// Непустая причина означает, что нативный ввод в элемент не попадёт.
+func (m *Manager) inputPoint(id int) (proto.Point, string, error) {
+	frame := m.elementFrame(id)
+
+	res, err := frame.Page.Evaluate(rod.Eval(inputTargetJS, id).ByPromise())
+	if err != nil {
+		return proto.Point{}, "", err
+	}
+	if !res.Value.Get("ok").Bool() {
+		return proto.Point{}, res.Value.Get("reason").Str(), nil
+	}
+
+	// Прокрутка внутри фрейма могла сдвинуть сам фрейм, поэтому смещение берём заново
+	if frame.Page != m.activePage() {
+		frame = m.elementFrame(id)
+	}
+
+	return proto.Point{
+		X: frame.OffsetX + res.Value.Get("x").Num(),
+		Y: frame.OffsetY + res.Value.Get("y").Num(),
+	}, "", nil
+}
+
+// clickElement кликает элемент настоящей мышью: наведение, пауза, нажатие в центре.
+// Если элемент скрыт, перекрыт или мышь недоступна, кликает через JS.
+func (m *Manager) clickElement(ctx context.Context, id int) (types.InputResult, error) {
+	point, reason, err := m.inputPoint(id)
+	if err != nil {
+		return types.InputResult{}, err
+	}
+
+	if reason == "" {
+		reason = m.nativeClick(ctx, point)
+		if reason == "" {
+			return types.InputResult{Method: types.InputNative}, nil
+		}
+	}
+	// Нажатие открыло диалог: повторный клик через JS после ответа был бы лишним
+	if err := ctx.Err(); err != nil {
+		return types.InputResult{}, err
+	}
+
+	// JS-клик от имени пользователя, чтобы не сработал блокировщик всплывающих окон
+	if _, err := m.frameForElement(id).Evaluate(rod.Eval(clickJS, id).ByUser()); err != nil {
+		return types.InputResult{}, err
+	}
+	return types.InputResult{Method: types.InputJS, Fallback: reason}, nil
+}
+
+// nativeClick возвращает причину неудачи или пустую строку
+func (m *Manager) nativeClick(ctx context.Context, point proto.Point) string {
+	page := m.activePage()
+	if err := page.Mouse.MoveTo(point); err != nil {
+		return fmt.Sprintf("mouse move failed: %v", err)
+	}
+	time.Sleep(hoverDelay)
+	// Диалог мог открыть обработчик наведения
+	if err := ctx.Err(); err != nil {
+		return err.Error()
+	}
+	if err := page.Mouse.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return fmt.Sprintf("mouse click failed: %v", err)
+	}
+	return ""
+}
+
+// hoverElement наводит на элемент настоящую мышь, а если это невозможно — шлёт события мыши из JS
+func (m *Manager) hoverElement(ctx context.Context, id int) (types.InputResult, error) {
+	point, reason, err := m.inputPoint(id)
+	if err != nil {
+		return types.InputResult{}, err
+	}
+
+	if reason == "" {
+		err := m.activePage().Mouse.MoveTo(point)
+		if err == nil {
+			return types.InputResult{Method: types.InputNative}, nil
+		}
+		reason = fmt.Sprintf("mouse move failed: %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return types.InputResult{}, err
+	}
+
+	if _, err := m.frameForElement(id).Eval(hoverJS, id); err != nil {
+		return types.InputResult{}, err
+	}
+	return types.InputResult{Method: types.InputJS, Fallback: reason}, nil
+}
+
+// typeElement фокусирует поле кликом, выделяет старое содержимое и вставляет текст
+// через Input.insertText — страница получает настоящие beforeinput/input. Если поле
+// перекрыто или значение после вставки не совпало с текстом, значение ставится из JS.
+// Даты, range и color текст не принимают — им значение ставится сразу.
+func (m *Manager) typeElement(ctx context.Context, id int, text string) (types.InputResult, error) {
+	frame := m.frameForElement(id)
+
+	kind := ""
+	if res, err := frame.Eval(inputKindJS, id); err == nil {
+		kind = res.Value.Str()
+	}
+	if kind == "select" {
+		return types.InputResult{}, fmt.Errorf("element is a <select>: use select_option")
+	}
+	if _, ok := valueFormats[kind]; ok {
+		accepted, err := m.setInputValue(id, kind, text)
+		if err != nil {
+			return types.InputResult{}, err
+		}
+		return types.InputResult{
+			Method:   types.InputJS,
+			Fallback: fmt.Sprintf("input type=%s does not accept typed text, value %q set directly", kind, accepted),
+		}, nil
+	}
+
+	point, reason, err := m.inputPoint(id)
+	if err != nil {
+		return types.InputResult{}, err
+	}
+
+	if reason == "" {
+		reason = m.nativeType(ctx, frame, id, point, text)
+		if reason == "" {
+			return types.InputResult{Method: types.InputNative}, nil
+		}
+	}
+	// После ответа на диалог поле могло измениться — не вводим текст вслепую
+	if err := ctx.Err(); err != nil {
+		return types.InputResult{}, err
+	}
+
+	if _, err := frame.Eval(setValueJS, map[string]interface{}{"id": id, "text": text}); err != nil {
+		return types.InputResult{}, err
+	}
+	return types.InputResult{Method: types.InputJS, Fallback: reason}, nil
+}
+
+// nativeType возвращает причину неудачи или пустую строку
+func (m *Manager) nativeType(ctx context.Context, frame *rod.Page, id int, point proto.Point, text string) string {
+	if reason := m.nativeClick(ctx, point); reason != "" {
+		return reason
+	}
+	if err := ctx.Err(); err != nil {
+		return err.Error()
+	}
+	if _, err := frame.Eval(selectContentJS, id); err != nil {
+		return fmt.Sprintf("focus failed: %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err.Error()
+	}
+	if err := frame.InsertText(text); err != nil {
+		return fmt.Sprintf("text input failed: %v", err)
+	}
+
+	res, err := frame.Eval(fieldValueJS, id)
+	if err != nil {
+		return fmt.Sprintf("read value failed: %v", err)
+	}
+	if !valueMatches(res.Value.Str(), text) {
+		return "the field did not accept typed text"
+	}
+	return ""
+}
+
+// valueMatches проверяет, что поле содержит введённый текст. Сравниваются только буквы и цифры:
+// маски полей (телефоны, карты, даты) добавляют свои разделители.
+func valueMatches(value, text string) bool {
+	normalize := func(s string) string {
+		var b strings.Builder
+		for _, r := range strings.ToLower(s) {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	}
+
+	want := normalize(text)
+	if want == "" {
+		return true
+	}
+	return strings.Contains(normalize(value), want)
+}
@@ -0,0 +1,29 @@
+package browser
+
+import "testing"
+
+func TestValueMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		text  string
+		want  bool
+	}{
+		{"exact", "hello world", "hello world", true},
+		{"case", "Hello World", "hello world", true},
+		{"phone mask", "+7 (999) 123-45-67", "79991234567", true},
+		{"card mask", "4111 1111 1111 1111", "4111111111111111", true},
+		{"prefix kept", "search: golang", "golang", true},
+		{"rejected", "", "golang", false},
+		{"truncated by maxlength", "gola", "golang", false},
+		{"only punctuation", "", "---", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := valueMatches(tt.value, tt.text); got != tt.want {
+				t.Errorf("valueMatches(%q, %q) = %v, want %v", tt.value, tt.text, got, tt.want)
+			}
+		})
+	}
+}
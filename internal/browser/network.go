@@ -0,0 +1,300 @@
+package browser
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/session"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// resourceTypes — имена типов ресурсов в правилах и соответствующие типы CDP
+var resourceTypes = map[string]proto.NetworkResourceType{
+	"document":    proto.NetworkResourceTypeDocument,
+	"stylesheet":  proto.NetworkResourceTypeStylesheet,
+	"image":       proto.NetworkResourceTypeImage,
+	"media":       proto.NetworkResourceTypeMedia,
+	"font":        proto.NetworkResourceTypeFont,
+	"script":      proto.NetworkResourceTypeScript,
+	"xhr":         proto.NetworkResourceTypeXHR,
+	"fetch":       proto.NetworkResourceTypeFetch,
+	"eventsource": proto.NetworkResourceTypeEventSource,
+	"websocket":   proto.NetworkResourceTypeWebSocket,
+	"manifest":    proto.NetworkResourceTypeManifest,
+	"ping":        proto.NetworkResourceTypePing,
+	"prefetch":    proto.NetworkResourceTypePrefetch,
+	"other":       proto.NetworkResourceTypeOther,
+}
+
+// LoadNetworkRules читает правила перехвата из YAML. Пути файлов подмены
+// приводятся к абсолютным относительно каталога файла правил.
+func LoadNetworkRules(path string) (*types.NetworkRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read network rules: %w", err)
+	}
+
+	var rules types.NetworkRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse network rules %s: %w", path, err)
+	}
+
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("resolve network rules dir: %w", err)
+	}
+	for i := range rules.Mocks {
+		if f := rules.Mocks[i].File; f != "" && !filepath.IsAbs(f) {
+			rules.Mocks[i].File = filepath.Join(base, f)
+		}
+	}
+
+	// Ошибки в правилах лучше показать при загрузке, а не при запуске браузера
+	if _, err := compileNetworkRules(&rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &rules, nil
+}
+
+// networkAction — что сделать с перехваченным запросом
+type networkAction int
+
+const (
+	actionContinue networkAction = iota
+	actionBlock
+	actionMock
+)
+
+// mockResponse — подготовленный ответ правила подмены
+type mockResponse struct {
+	pattern     *regexp.Regexp
+	status      int
+	body        []byte
+	contentType string
+	headers     map[string]string
+}
+
+// networkRules — скомпилированные правила перехвата
+type networkRules struct {
+	blockTypes   map[proto.NetworkResourceType]bool
+	blockDomains []string
+	mocks        []*mockResponse
+
+	blocked atomic.Int64
+	mocked  atomic.Int64
+}
+
+func compileNetworkRules(rules *types.NetworkRules) (*networkRules, error) {
+	n := &networkRules{
+		blockTypes:   map[proto.NetworkResourceType]bool{},
+		blockDomains: rules.BlockDomains,
+	}
+
+	for _, name := range rules.BlockTypes {
+		t, ok := resourceTypes[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown resource type %q", name)
+		}
+		n.blockTypes[t] = true
+	}
+
+	for i, m := range rules.Mocks {
+		if m.URL == "" || m.File == "" {
+			return nil, fmt.Errorf("mock %d: url and file are required", i+1)
+		}
+		body, err := os.ReadFile(m.File)
+		if err != nil {
+			return nil, fmt.Errorf("mock %s: %w", m.URL, err)
+		}
+
+		mock := &mockResponse{
+			pattern:     urlPattern(m.URL),
+			status:      m.Status,
+			body:        body,
+			contentType: m.ContentType,
+			headers:     m.Headers,
+		}
+		if mock.status == 0 {
+			mock.status = http.StatusOK
+		}
+		if mock.contentType == "" {
+			mock.contentType = mime.TypeByExtension(filepath.Ext(m.File))
+		}
+		if mock.contentType == "" {
+			mock.contentType = http.DetectContentType(body)
+		}
+		n.mocks = append(n.mocks, mock)
+	}
+
+	return n, nil
+}
+
+// urlPattern переводит шаблон URL с * в регулярное выражение
+func urlPattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(strings.TrimSpace(pattern), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile(`\A` + strings.Join(parts, ".*") + `\z`)
+}
+
+// decide выбирает действие для запроса: подмена, затем блокировка домена, затем типа ресурса
+func (n *networkRules) decide(rawURL string, resType proto.NetworkResourceType) (networkAction, *mockResponse) {
+	for _, m := range n.mocks {
+		if m.pattern.MatchString(rawURL) {
+			return actionMock, m
+		}
+	}
+
+	if len(n.blockDomains) > 0 {
+		if host := requestHost(rawURL); host != "" && session.MatchDomain(host, n.blockDomains) {
+			return actionBlock, nil
+		}
+	}
+	if n.blockTypes[resType] {
+		return actionBlock, nil
+	}
+	return actionContinue, nil
+}
+
+// handle применяет правила к приостановленному запросу
+func (n *networkRules) handle(client proto.Client, e *proto.FetchRequestPaused) error {
+	action, mock := n.decide(e.Request.URL, e.ResourceType)
+	switch action {
+	case actionBlock:
+		n.blocked.Add(1)
+		return proto.FetchFailRequest{
+			RequestID:   e.RequestID,
+			ErrorReason: proto.NetworkErrorReasonBlockedByClient,
+		}.Call(client)
+	case actionMock:
+		n.mocked.Add(1)
+		headers := []*proto.FetchHeaderEntry{{Name: "Content-Type", Value: mock.contentType}}
+		for k, v := range mock.headers {
+			headers = append(headers, &proto.FetchHeaderEntry{Name: k, Value: v})
+		}
+		return proto.FetchFulfillRequest{
+			RequestID:       e.RequestID,
+			ResponseCode:    mock.status,
+			ResponseHeaders: headers,
+			Body:            mock.body,
+		}.Call(client)
+	default:
+		return proto.FetchContinueRequest{RequestID: e.RequestID}.Call(client)
+	}
+}
+
+// startInterception включает перехват запросов всех вкладок браузера: правила config.Network
+// и ответы на авторизацию прокси. Fetch включается один раз — второй Fetch.enable заменил бы
+// шаблоны первого. Если правила только блокируют типы ресурсов, а прокси без пароля, браузер
+// приостанавливает лишь запросы этих типов; блокировка доменов, подмена и авторизация прокси
+// требуют приостановки каждого запроса.
+func (m *Manager) startInterception(proxyUser, proxyPassword string) error {
+	var rules *networkRules
+	if m.config.Network != nil {
+		var err error
+		rules, err = compileNetworkRules(m.config.Network)
+		if err != nil {
+			return fmt.Errorf("network rules: %w", err)
+		}
+		if len(rules.blockTypes) == 0 && len(rules.blockDomains) == 0 && len(rules.mocks) == 0 {
+			rules = nil
+		}
+	}
+	var auth *proxyAuth
+	if proxyUser != "" {
+		auth = &proxyAuth{username: proxyUser, password: proxyPassword, tried: map[proto.FetchRequestID]time.Time{}}
+	}
+	if rules == nil && auth == nil {
+		return nil
+	}
+
+	enable := proto.FetchEnable{HandleAuthRequests: auth != nil}
+	if auth != nil || len(rules.blockDomains) > 0 || len(rules.mocks) > 0 {
+		enable.Patterns = []*proto.FetchRequestPattern{{URLPattern: "*"}}
+	} else {
+		for t := range rules.blockTypes {
+			enable.Patterns = append(enable.Patterns, &proto.FetchRequestPattern{URLPattern: "*", ResourceType: t})
+		}
+	}
+
+	// Подписка до включения Fetch: приостановленный запрос без ответа висел бы до таймаута
+	b, stop := m.browser.WithCancel()
+	wait := b.EachEvent(func(e *proto.FetchRequestPaused) {
+		go func() {
+			var err error
+			if rules != nil {
+				err = rules.handle(m.browser, e)
+			} else {
+				err = proto.FetchContinueRequest{RequestID: e.RequestID}.Call(m.browser)
+			}
+			if err != nil && m.config.Debug {
+				m.log.Debug("Failed to answer intercepted request", "url", e.Request.URL, "error", err.Error())
+			}
+		}()
+	}, func(e *proto.FetchAuthRequired) {
+		go func() {
+			// Без пароля прокси Fetch не просит авторизации, но ответить нужно в любом случае
+			res := &proto.FetchAuthChallengeResponse{Response: proto.FetchAuthChallengeResponseResponseDefault}
+			if auth != nil {
+				res = auth.response(e)
+			}
+			err := proto.FetchContinueWithAuth{RequestID: e.RequestID, AuthChallengeResponse: res}.Call(m.browser)
+			if err != nil && m.config.Debug {
+				m.log.Debug("Failed to answer proxy auth", "error", err.Error())
+			}
+		}()
+	})
+	if err := enable.Call(m.browser); err != nil {
+		stop()
+		return fmt.Errorf("enable request interception: %w", err)
+	}
+	go wait()
+
+	m.intercept = stop
+	m.network = rules
+	if m.config.Debug {
+		if rules != nil {
+			m.log.Debug("Network rules enabled", "blockTypes", len(rules.blockTypes), "blockDomains", len(rules.blockDomains), "mocks", len(rules.mocks))
+		}
+		if auth != nil {
+			m.log.Debug("Proxy authentication enabled", "username", proxyUser)
+		}
+	}
+	return nil
+}
+
+// NetworkStats возвращает число заблокированных и подменённых запросов с запуска
+func (m *Manager) NetworkStats() (blocked, mocked int64) {
+	if m.network == nil {
+		return 0, 0
+	}
+	return m.network.blocked.Load(), m.network.mocked.Load()
+}
+
+func (m *Manager) stopInterception() {
+	if m.intercept == nil {
+		return
+	}
+	m.intercept()
+	m.intercept = nil
+	_ = proto.FetchDisable{}.Call(m.browser)
+}
+
+func requestHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,188 @@
+package browser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func TestNetworkRules_Decide(t *testing.T) {
+	dir := t.TempDir()
+	mockFile := filepath.Join(dir, "items.json")
+	if err := os.WriteFile(mockFile, []byte(`{"items": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := compileNetworkRules(&types.NetworkRules{
+		BlockTypes:   []string{"image", "Font"},
+		BlockDomains: []string{"doubleclick.net", "ads.example.com"},
+		Mocks: []types.MockRule{
+			{URL: "https://api.example.com/v1/items?page=*", File: mockFile},
+			{URL: "https://ads.example.com/offline.png", File: mockFile, Status: 404, ContentType: "image/png"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url      string
+		resType  proto.NetworkResourceType
+		want     networkAction
+		wantCode int
+	}{
+		{"https://example.com/", proto.NetworkResourceTypeDocument, actionContinue, 0},
+		{"https://example.com/logo.png", proto.NetworkResourceTypeImage, actionBlock, 0},
+		{"https://fonts.example.com/a.woff2", proto.NetworkResourceTypeFont, actionBlock, 0},
+		{"https://stats.g.doubleclick.net/collect", proto.NetworkResourceTypeXHR, actionBlock, 0},
+		{"https://doubleclick.net.example.com/", proto.NetworkResourceTypeScript, actionContinue, 0},
+		{"https://api.example.com/v1/items?page=2", proto.NetworkResourceTypeFetch, actionMock, 200},
+		// ? в шаблоне — обычный символ, а не подстановка
+		{"https://api.example.com/v1/itemsXpage=2", proto.NetworkResourceTypeFetch, actionContinue, 0},
+		// Подмена важнее блокировки домена
+		{"https://ads.example.com/offline.png", proto.NetworkResourceTypeImage, actionMock, 404},
+		{"https://ads.example.com/banner.js", proto.NetworkResourceTypeScript, actionBlock, 0},
+	}
+
+	for _, tt := range tests {
+		got, mock := rules.decide(tt.url, tt.resType)
+		if got != tt.want {
+			t.Errorf("decide(%s) = %v, want %v", tt.url, got, tt.want)
+			continue
+		}
+		if got == actionMock && mock.status != tt.wantCode {
+			t.Errorf("decide(%s) status = %d, want %d", tt.url, mock.status, tt.wantCode)
+		}
+	}
+
+	if ct := rules.mocks[0].contentType; ct != "application/json" {
+		t.Errorf("content type by extension = %q", ct)
+	}
+	if ct := rules.mocks[1].contentType; ct != "image/png" {
+		t.Errorf("explicit content type = %q", ct)
+	}
+}
+
+func TestCompileNetworkRules_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules types.NetworkRules
+		want  string
+	}{
+		{"unknown type", types.NetworkRules{BlockTypes: []string{"video"}}, `unknown resource type "video"`},
+		{"mock without file", types.NetworkRules{Mocks: []types.MockRule{{URL: "https://a.com/*"}}}, "url and file are required"},
+		{"missing file", types.NetworkRules{Mocks: []types.MockRule{{URL: "https://a.com/*", File: "/nonexistent/mock.json"}}}, "mock https://a.com/*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileNetworkRules(&tt.rules)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadNetworkRules(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "mocks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mocks", "search.html"), []byte("<html></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "network.yaml")
+	yaml := `block_types: [image, media]
+block_domains: [mc.yandex.ru]
+mocks:
+  - url: "https://hh.ru/search/vacancy*"
+    file: mocks/search.html
+    headers:
+      X-Mock: "1"
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadNetworkRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules.BlockTypes) != 2 || rules.BlockDomains[0] != "mc.yandex.ru" || len(rules.Mocks) != 1 {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	// Путь файла подмены считается от файла правил, а не от рабочего каталога
+	if want := filepath.Join(dir, "mocks", "search.html"); rules.Mocks[0].File != want {
+		t.Errorf("mock file = %q, want %q", rules.Mocks[0].File, want)
+	}
+	if rules.Mocks[0].Headers["X-Mock"] != "1" {
+		t.Errorf("mock headers not parsed: %+v", rules.Mocks[0])
+	}
+
+	if err := os.WriteFile(path, []byte("block_types: [pictures]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadNetworkRules(path); err == nil {
+		t.Error("invalid rules should fail to load")
+	}
+}
+
+// fetchClient запоминает вызовы CDP, которыми отвечают на приостановленные запросы
+type fetchClient struct {
+	methods []string
+	params  []interface{}
+}
+
+func (c *fetchClient) Call(_ context.Context, _, method string, params interface{}) ([]byte, error) {
+	c.methods = append(c.methods, method)
+	c.params = append(c.params, params)
+	return nil, nil
+}
+
+func TestNetworkRules_Handle(t *testing.T) {
+	dir := t.TempDir()
+	mockFile := filepath.Join(dir, "items.json")
+	if err := os.WriteFile(mockFile, []byte(`[]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := compileNetworkRules(&types.NetworkRules{
+		BlockTypes: []string{"image"},
+		Mocks:      []types.MockRule{{URL: "https://api.example.com/*", File: mockFile, Headers: map[string]string{"X-Mock": "1"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paused := func(url string, resType proto.NetworkResourceType) *proto.FetchRequestPaused {
+		return &proto.FetchRequestPaused{RequestID: "r", Request: &proto.NetworkRequest{URL: url}, ResourceType: resType}
+	}
+	client := &fetchClient{}
+	for _, e := range []*proto.FetchRequestPaused{
+		paused("https://example.com/logo.png", proto.NetworkResourceTypeImage),
+		paused("https://api.example.com/items", proto.NetworkResourceTypeFetch),
+		paused("https://example.com/", proto.NetworkResourceTypeDocument),
+	} {
+		if err := rules.handle(client, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"Fetch.failRequest", "Fetch.fulfillRequest", "Fetch.continueRequest"}
+	if strings.Join(client.methods, ",") != strings.Join(want, ",") {
+		t.Fatalf("methods = %v, want %v", client.methods, want)
+	}
+	fulfill := client.params[1].(proto.FetchFulfillRequest)
+	if fulfill.ResponseCode != 200 || string(fulfill.Body) != "[]" || len(fulfill.ResponseHeaders) != 2 {
+		t.Errorf("mock response = %+v", fulfill)
+	}
+	if blocked, mocked := rules.blocked.Load(), rules.mocked.Load(); blocked != 1 || mocked != 1 {
+		t.Errorf("stats = %d blocked, %d mocked", blocked, mocked)
+	}
+}
@@ -0,0 +1,185 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const (
+	// defaultScreenshotMaxSize — ограничение по длинной стороне скриншота, в пикселях
+	defaultScreenshotMaxSize = 1280
+	// elementPadding — поля вокруг элемента при вырезании, в CSS-пикселях
+	elementPadding = 8
+)
+
+// Screenshot снимает видимую область вкладки в PNG. Если id >= 0, снимок обрезается
+// по элементу с этим ID из extract_page (элемент предварительно прокручивается в видимую область).
+// Длинная сторона изображения не превышает BrowserConfig.ScreenshotMaxSize.
+func (m *Manager) Screenshot(ctx context.Context, id int) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	var clip *rect
+	if id >= 0 {
+		r, err := m.elementRect(id)
+		if err != nil {
+			return nil, err
+		}
+		clip = r
+	}
+
+	img, scale, err := m.captureViewport()
+	if err != nil {
+		return nil, err
+	}
+
+	if clip != nil {
+		bounds := image.Rect(
+			int((clip.X-elementPadding)*scale),
+			int((clip.Y-elementPadding)*scale),
+			int((clip.X+clip.Width+elementPadding)*scale),
+			int((clip.Y+clip.Height+elementPadding)*scale),
+		).Intersect(img.Bounds())
+		if bounds.Empty() {
+			return nil, fmt.Errorf("element [%d] is outside the visible area", id)
+		}
+		img = cropImage(img, bounds)
+	}
+
+	maxSize := m.config.ScreenshotMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultScreenshotMaxSize
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaleImage(img, maxSize)); err != nil {
+		return nil, fmt.Errorf("encode screenshot: %w", err)
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Screenshot captured", "id", id, "bytes", buf.Len())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// rect — прямоугольник в CSS-пикселях относительно viewport вкладки
+type rect struct {
+	X, Y, Width, Height float64
+}
+
+// captureViewport снимает видимую область вкладки. scale — число пикселей изображения
+// на CSS-пиксель (devicePixelRatio).
+func (m *Manager) captureViewport() (*image.RGBA, float64, error) {
+	page := m.activePage()
+	data, err := page.Screenshot(false, &proto.PageCaptureScreenshot{
+		Format: proto.PageCaptureScreenshotFormatPng,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("capture screenshot: %w", err)
+	}
+
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("decode screenshot: %w", err)
+	}
+
+	img := image.NewRGBA(decoded.Bounds())
+	draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+
+	scale := 1.0
+	if res, err := page.Eval(`() => window.innerWidth`); err == nil {
+		if width := res.Value.Num(); width > 0 {
+			scale = float64(img.Bounds().Dx()) / width
+		}
+	}
+
+	return img, scale, nil
+}
+
+// elementRect прокручивает элемент в видимую область и возвращает его прямоугольник
+// в координатах вкладки
+func (m *Manager) elementRect(id int) (*rect, error) {
+	frame := m.elementFrame(id)
+
+	res, err := frame.Page.Eval(`(id) => {
+		if (!window._ai_elements || !(id in window._ai_elements)) {
+			throw new Error("Element ID " + id + " not found. Call extract_page to get current IDs.");
+		}
+		const el = window._ai_resolve(id);
+		if (!el) {
+			throw new Error("Element ID " + id + " is no longer on the page. Call extract_page again.");
+		}
+		el.scrollIntoView({block: "center", inline: "center"});
+		const r = el.getBoundingClientRect();
+		return {x: r.left, y: r.top, width: r.width, height: r.height};
+	}`, id)
+	if err != nil {
+		return nil, fmt.Errorf("locate element [%d]: %w", id, err)
+	}
+
+	// Прокрутка внутри фрейма могла сдвинуть сам фрейм, поэтому смещение берём заново
+	if frame.Page != m.activePage() {
+		frame = m.elementFrame(id)
+	}
+
+	return &rect{
+		X:      res.Value.Get("x").Num() + frame.OffsetX,
+		Y:      res.Value.Get("y").Num() + frame.OffsetY,
+		Width:  res.Value.Get("width").Num(),
+		Height: res.Value.Get("height").Num(),
+	}, nil
+}
+
+// cropImage возвращает копию области изображения
+func cropImage(img *image.RGBA, bounds image.Rectangle) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Src)
+	return out
+}
+
+// scaleImage уменьшает изображение так, чтобы длинная сторона не превышала maxSize.
+// Каждый пиксель результата — среднее соответствующего блока исходных пикселей.
+func scaleImage(img *image.RGBA, maxSize int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+
+	ratio := float64(maxSize) / float64(max(w, h))
+	dw, dh := max(1, int(float64(w)*ratio)), max(1, int(float64(h)*ratio))
+	out := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	origin := img.Bounds().Min
+
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := img.RGBAAt(origin.X+sx, origin.Y+sy)
+					r += uint32(c.R)
+					g += uint32(c.G)
+					b += uint32(c.B)
+					a += uint32(c.A)
+					n++
+				}
+			}
+			out.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,49 @@
+package browser
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestScaleImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			// Левая половина чёрная, правая белая
+			c := color.RGBA{A: 255}
+			if x >= 200 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	scaled := scaleImage(img, 100)
+	if got := scaled.Bounds().Size(); got != image.Pt(100, 50) {
+		t.Fatalf("got size %v, want 100x50", got)
+	}
+	if c := scaled.RGBAAt(10, 10); c.R != 0 {
+		t.Errorf("left side should stay black, got %v", c)
+	}
+	if c := scaled.RGBAAt(90, 10); c.R != 255 {
+		t.Errorf("right side should stay white, got %v", c)
+	}
+
+	if small := scaleImage(img, 1000); small != img {
+		t.Error("image within the limit should be returned as is")
+	}
+}
+
+func TestCropImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 50, 50))
+	img.SetRGBA(20, 30, color.RGBA{R: 255, A: 255})
+
+	cropped := cropImage(img, image.Rect(10, 20, 40, 45))
+	if got := cropped.Bounds(); got != image.Rect(0, 0, 30, 25) {
+		t.Fatalf("got bounds %v, want (0,0)-(30,25)", got)
+	}
+	if c := cropped.RGBAAt(10, 10); c.R != 255 {
+		t.Errorf("pixel should move with the crop, got %v", c)
+	}
+}
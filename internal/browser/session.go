@@ -0,0 +1,350 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/session"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// originPageTimeout — сколько ждать открытия пустой страницы origin для доступа к хранилищу
+const originPageTimeout = 10 * time.Second
+
+// readStorageJS возвращает содержимое localStorage или sessionStorage страницы
+const readStorageJS = `(kind) => {
+	const storage = window[kind];
+	const items = {};
+	for (let i = 0; i < storage.length; i++) {
+		const key = storage.key(i);
+		items[key] = storage.getItem(key);
+	}
+	return items;
+}`
+
+// writeStorageJS записывает ключи в localStorage или sessionStorage страницы
+const writeStorageJS = `(args) => {
+	const storage = window[args.kind];
+	for (const [key, value] of Object.entries(args.items)) {
+		storage.setItem(key, value);
+	}
+	return true;
+}`
+
+// seedSessionStorageJS заполняет sessionStorage при первом открытии origin во вкладке.
+// sessionStorage живёт только внутри вкладки, поэтому заранее записать его нельзя.
+// Метка не даёт вернуть ключи, которые сайт удалил сам (например, при выходе).
+const seedSessionStorageJS = `(() => {
+	const data = %s;
+	const items = data[location.origin];
+	if (!items) return;
+	try {
+		if (sessionStorage.getItem("_ai_session_seeded")) return;
+		for (const [key, value] of Object.entries(items)) {
+			sessionStorage.setItem(key, value);
+		}
+		sessionStorage.setItem("_ai_session_seeded", "1");
+	} catch (e) {}
+})()`
+
+// ExportSession собирает cookies, localStorage и sessionStorage доменов (пусто — всех).
+// localStorage читается на пустой странице каждого origin, отданной без обращения к сайту,
+// sessionStorage — из открытых вкладок этих доменов.
+func (m *Manager) ExportSession(ctx context.Context, domains []string) (*types.SessionState, error) {
+	state := &types.SessionState{
+		ExportedAt: time.Now().UTC(),
+		Domains:    domains,
+		Cookies:    []types.SessionCookie{},
+		Origins:    []types.OriginStorage{},
+	}
+
+	res, err := proto.StorageGetCookies{BrowserContextID: m.browser.BrowserContextID}.Call(m.browser)
+	if err != nil {
+		return nil, fmt.Errorf("get cookies: %w", err)
+	}
+	for _, c := range res.Cookies {
+		if session.MatchDomain(c.Domain, domains) {
+			state.Cookies = append(state.Cookies, sessionCookie(c))
+		}
+	}
+
+	storages := map[string]*types.OriginStorage{}
+	storage := func(origin string) *types.OriginStorage {
+		if s, ok := storages[origin]; ok {
+			return s
+		}
+		s := &types.OriginStorage{Origin: origin}
+		storages[origin] = s
+		return s
+	}
+
+	origins := session.Origins(domains, state.Cookies)
+	err = m.withOriginPages(ctx, origins, func(page *rod.Page, origin string) error {
+		items, err := readStorage(page, "localStorage")
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			storage(origin).LocalStorage = items
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, page := range m.tabPages() {
+		info, err := page.Info()
+		if err != nil {
+			continue
+		}
+		origin := pageOrigin(info.URL)
+		if origin == "" || !session.MatchOrigin(origin, domains) {
+			continue
+		}
+		p := page.Context(ctx).Timeout(originPageTimeout)
+		items, err := readStorage(p, "sessionStorage")
+		p.CancelTimeout()
+		if err == nil && len(items) > 0 {
+			storage(origin).SessionStorage = items
+		}
+	}
+
+	for _, origin := range append(origins, sortedKeys(storages)...) {
+		if s, ok := storages[origin]; ok {
+			state.Origins = append(state.Origins, *s)
+			delete(storages, origin)
+		}
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Session exported", "cookies", len(state.Cookies), "origins", len(state.Origins))
+	}
+	return state, nil
+}
+
+// ImportSession записывает cookies и localStorage в профиль браузера. sessionStorage
+// переживает только текущий запуск: он заполняется при открытии origin в активной вкладке.
+func (m *Manager) ImportSession(ctx context.Context, state *types.SessionState) error {
+	now := float64(time.Now().Unix())
+
+	var cookies []*proto.NetworkCookieParam
+	for _, c := range state.Cookies {
+		// Истёкшие cookie браузер всё равно удалит
+		if c.Expires > 0 && c.Expires < now {
+			continue
+		}
+		cookies = append(cookies, &proto.NetworkCookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+			SameSite: proto.NetworkCookieSameSite(c.SameSite),
+			Expires:  proto.TimeSinceEpoch(c.Expires),
+		})
+	}
+	if len(cookies) > 0 {
+		if err := (proto.StorageSetCookies{Cookies: cookies, BrowserContextID: m.browser.BrowserContextID}).Call(m.browser); err != nil {
+			return fmt.Errorf("set cookies: %w", err)
+		}
+	}
+
+	local := map[string]map[string]string{}
+	seed := map[string]map[string]string{}
+	var origins []string
+	for _, o := range state.Origins {
+		if len(o.LocalStorage) > 0 {
+			local[o.Origin] = o.LocalStorage
+			origins = append(origins, o.Origin)
+		}
+		if len(o.SessionStorage) > 0 {
+			seed[o.Origin] = o.SessionStorage
+		}
+	}
+
+	err := m.withOriginPages(ctx, origins, func(page *rod.Page, origin string) error {
+		_, err := page.Eval(writeStorageJS, map[string]interface{}{"kind": "localStorage", "items": local[origin]})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(seed) > 0 {
+		data, err := json.Marshal(seed)
+		if err != nil {
+			return fmt.Errorf("marshal session storage: %w", err)
+		}
+		if _, err := m.activePage().EvalOnNewDocument(fmt.Sprintf(seedSessionStorageJS, data)); err != nil {
+			return fmt.Errorf("seed session storage: %w", err)
+		}
+	}
+
+	if m.config.Debug {
+		m.log.Debug("Session imported", "cookies", len(cookies), "localStorage", len(local), "sessionStorage", len(seed))
+	}
+	return nil
+}
+
+// LoginDomains возвращает сайты, на которые, судя по cookies, выполнен вход: домены
+// постоянных HttpOnly cookie, в которых сайты обычно хранят токен сессии.
+func (m *Manager) LoginDomains() ([]string, error) {
+	res, err := proto.StorageGetCookies{BrowserContextID: m.browser.BrowserContextID}.Call(m.browser)
+	if err != nil {
+		return nil, fmt.Errorf("get cookies: %w", err)
+	}
+
+	seen := map[string]bool{}
+	domains := []string{}
+	for _, c := range res.Cookies {
+		if !c.HTTPOnly || c.Session {
+			continue
+		}
+		if d := siteDomain(c.Domain); d != "" && !seen[d] {
+			seen[d] = true
+			domains = append(domains, d)
+		}
+	}
+	sort.Strings(domains)
+	return domains, nil
+}
+
+var secondLevelZones = map[string]bool{
+	"co": true, "com": true, "net": true, "org": true, "gov": true, "edu": true, "ac": true, "msk": true, "spb": true,
+}
+
+// siteDomain сводит хост к домену сайта: e.mail.ru → mail.ru, www.bbc.co.uk → bbc.co.uk.
+// Общие вторые уровни национальных зон (co.uk, com.ru) считаются частью зоны.
+func siteDomain(host string) string {
+	host = strings.ToLower(strings.TrimPrefix(host, "."))
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+
+	labels := strings.Split(host, ".")
+	n := 2
+	if len(labels) > 2 && len(labels[len(labels)-1]) == 2 && secondLevelZones[labels[len(labels)-2]] {
+		n = 3
+	}
+	if len(labels) <= n {
+		return host
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+// withOriginPages открывает в фоновой вкладке пустую страницу каждого origin и вызывает fn.
+// Документ отдаётся перехватом запросов: сайт не загружается, но страница получает его origin
+// и доступ к его хранилищам.
+func (m *Manager) withOriginPages(ctx context.Context, origins []string, fn func(page *rod.Page, origin string) error) error {
+	if len(origins) == 0 {
+		return nil
+	}
+
+	page, err := m.browser.Page(proto.TargetCreateTarget{URL: "about:blank", Background: true})
+	if err != nil {
+		return fmt.Errorf("open storage tab: %w", err)
+	}
+	defer func() { _ = page.Close() }()
+
+	router := page.HijackRequests()
+	err = router.Add("*", "", func(h *rod.Hijack) {
+		h.Response.SetHeader("Content-Type", "text/html; charset=utf-8")
+		h.Response.SetBody("<!DOCTYPE html><title></title>")
+	})
+	if err != nil {
+		return fmt.Errorf("intercept storage tab requests: %w", err)
+	}
+	go router.Run()
+	defer func() { _ = router.Stop() }()
+
+	for _, origin := range origins {
+		if err := openOriginPage(ctx, page, origin, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func openOriginPage(ctx context.Context, page *rod.Page, origin string, fn func(page *rod.Page, origin string) error) error {
+	p := page.Context(ctx).Timeout(originPageTimeout)
+	defer p.CancelTimeout()
+
+	if err := p.Navigate(origin + "/"); err != nil {
+		return fmt.Errorf("open %s: %w", origin, err)
+	}
+	if err := p.WaitLoad(); err != nil {
+		return fmt.Errorf("open %s: %w", origin, err)
+	}
+	if err := fn(p, origin); err != nil {
+		return fmt.Errorf("storage of %s: %w", origin, err)
+	}
+	return nil
+}
+
+// tabPages возвращает страницы открытых вкладок
+func (m *Manager) tabPages() []*rod.Page {
+	m.tabs.mu.Lock()
+	defer m.tabs.mu.Unlock()
+
+	pages := make([]*rod.Page, 0, len(m.tabs.tabs))
+	for _, t := range m.tabs.tabs {
+		pages = append(pages, t.page)
+	}
+	return pages
+}
+
+func readStorage(page *rod.Page, kind string) (map[string]string, error) {
+	res, err := page.Eval(readStorageJS, kind)
+	if err != nil {
+		return nil, err
+	}
+	items := map[string]string{}
+	for key, value := range res.Value.Map() {
+		items[key] = value.Str()
+	}
+	return items, nil
+}
+
+func sessionCookie(c *proto.NetworkCookie) types.SessionCookie {
+	sc := types.SessionCookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		HTTPOnly: c.HTTPOnly,
+		Secure:   c.Secure,
+		SameSite: string(c.SameSite),
+	}
+	if !c.Session {
+		sc.Expires = float64(c.Expires)
+	}
+	return sc
+}
+
+// pageOrigin возвращает origin страницы (https://mail.ru) или пустую строку для about:blank и т.п.
+func pageOrigin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func sortedKeys(m map[string]*types.OriginStorage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
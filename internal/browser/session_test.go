@@ -0,0 +1,57 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestPageOrigin(t *testing.T) {
+	tests := map[string]string{
+		"https://e.mail.ru/inbox/?page=2": "https://e.mail.ru",
+		"http://localhost:8080/app":       "http://localhost:8080",
+		"about:blank":                     "",
+		"chrome://newtab/":                "",
+		"":                                "",
+	}
+	for raw, want := range tests {
+		if got := pageOrigin(raw); got != want {
+			t.Errorf("pageOrigin(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestSessionCookie(t *testing.T) {
+	persistent := sessionCookie(&proto.NetworkCookie{
+		Name: "sid", Value: "v", Domain: ".mail.ru", Path: "/", Expires: 1900000000,
+		HTTPOnly: true, Secure: true, SameSite: proto.NetworkCookieSameSiteLax,
+	})
+	if persistent.Expires != 1900000000 || !persistent.HTTPOnly || persistent.SameSite != "Lax" {
+		t.Errorf("unexpected cookie: %+v", persistent)
+	}
+
+	// У сессионных cookie Chrome отдаёт expires=-1 — в файле это отсутствие срока
+	transient := sessionCookie(&proto.NetworkCookie{Name: "tmp", Expires: -1, Session: true})
+	if transient.Expires != 0 {
+		t.Errorf("session cookie should have no expiry, got %v", transient.Expires)
+	}
+}
+
+func TestSiteDomain(t *testing.T) {
+	tests := map[string]string{
+		".mail.ru":            "mail.ru",
+		"e.mail.ru":           "mail.ru",
+		"mail.ru":             "mail.ru",
+		"accounts.Google.com": "google.com",
+		"www.bbc.co.uk":       "bbc.co.uk",
+		"bbc.co.uk":           "bbc.co.uk",
+		"auth.site.com.ru":    "site.com.ru",
+		"localhost":           "localhost",
+		"192.168.0.10":        "192.168.0.10",
+	}
+	for host, want := range tests {
+		if got := siteDomain(host); got != want {
+			t.Errorf("siteDomain(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
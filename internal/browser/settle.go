@@ -0,0 +1,283 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+const (
+	defaultNetworkIdle   = 500 * time.Millisecond
+	defaultDOMQuiet      = 300 * time.Millisecond
+	defaultSettleMaxWait = 10 * time.Second
+	// settlePoll — интервал проверки состояния страницы
+	settlePoll = 100 * time.Millisecond
+	// settleMaxURLs — сколько незавершённых запросов перечислять в отчёте
+	settleMaxURLs = 3
+)
+
+// settleIgnoredTypes — запросы, которые могут длиться сколько угодно и не влияют на готовность страницы
+var settleIgnoredTypes = map[proto.NetworkResourceType]bool{
+	proto.NetworkResourceTypeWebSocket:   true,
+	proto.NetworkResourceTypeEventSource: true,
+	proto.NetworkResourceTypeMedia:       true,
+	proto.NetworkResourceTypeImage:       true,
+	proto.NetworkResourceTypeFont:        true,
+}
+
+// settleScript ставит на документ MutationObserver и возвращает, сколько мс DOM не менялся,
+// и readyState. Observer живёт до смены документа, поэтому ставится заново после навигации.
+const settleScript = `() => {
+	if (!window._ai_mutations) {
+		window._ai_mutations = {last: Date.now()};
+		new MutationObserver(() => { window._ai_mutations.last = Date.now(); })
+			.observe(document, {subtree: true, childList: true, characterData: true});
+	}
+	return {quiet: Date.now() - window._ai_mutations.last, ready: document.readyState};
+}`
+
+// settleWatcher следит за запросами и загрузкой документа вкладки, начиная с момента перед действием
+type settleWatcher struct {
+	page    *rod.Page
+	started time.Time
+	cancel  func()
+
+	mu          sync.Mutex
+	inflight    map[proto.NetworkRequestID]string
+	lastNetwork time.Time
+	loading     bool
+
+	// dialog закрывается, когда на вкладке открылся JS-диалог: страница ждёт ответа
+	dialog     chan struct{}
+	dialogOnce sync.Once
+}
+
+// settleSnapshot — состояние страницы в момент проверки
+type settleSnapshot struct {
+	// Inflight — URL незавершённых запросов
+	Inflight []string
+	// NetworkIdle — сколько прошло с последнего начала или завершения запроса
+	NetworkIdle time.Duration
+	// Loading — документ загружается (идёт навигация)
+	Loading bool
+	// DOMKnown — удалось опросить документ; во время смены документа это не так
+	DOMKnown bool
+	// DOMQuiet — сколько DOM не менялся
+	DOMQuiet time.Duration
+}
+
+// pending возвращает причины, по которым страница ещё не успокоилась
+func (s settleSnapshot) pending(cfg types.SettleConfig) []string {
+	var reasons []string
+
+	if s.Loading || !s.DOMKnown {
+		reasons = append(reasons, "navigation in progress")
+	}
+
+	if len(s.Inflight) > cfg.MaxInflight {
+		urls := s.Inflight
+		if len(urls) > settleMaxURLs {
+			urls = urls[:settleMaxURLs]
+		}
+		reasons = append(reasons, fmt.Sprintf("%d network requests in flight: %s", len(s.Inflight), strings.Join(urls, ", ")))
+	} else if s.NetworkIdle < cfg.NetworkIdle {
+		reasons = append(reasons, "network is busy")
+	}
+
+	if s.DOMKnown && s.DOMQuiet < cfg.DOMQuiet {
+		reasons = append(reasons, "page content is still changing")
+	}
+
+	return reasons
+}
+
+// settleConfig возвращает настройки ожидания с подставленными значениями по умолчанию
+func (m *Manager) settleConfig() types.SettleConfig {
+	cfg := m.config.Settle
+	if cfg.MaxInflight < 0 {
+		cfg.MaxInflight = 0
+	}
+	if cfg.NetworkIdle <= 0 {
+		cfg.NetworkIdle = defaultNetworkIdle
+	}
+	if cfg.DOMQuiet <= 0 {
+		cfg.DOMQuiet = defaultDOMQuiet
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = defaultSettleMaxWait
+	}
+	return cfg
+}
+
+// watchSettle начинает следить за активной вкладкой. Вызывается до действия, чтобы не пропустить
+// запросы и навигацию, которые оно запустит; затем нужно вызвать settle.
+func (m *Manager) watchSettle() *settleWatcher {
+	active := m.activePage()
+	page, cancel := active.WithCancel()
+	now := time.Now()
+	w := &settleWatcher{
+		page:        page,
+		started:     now,
+		cancel:      cancel,
+		inflight:    map[proto.NetworkRequestID]string{},
+		lastNetwork: now,
+		dialog:      make(chan struct{}),
+	}
+
+	mainFrame := active.FrameID
+	session := active.SessionID
+	wait := page.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		if settleIgnoredTypes[e.Type] {
+			return
+		}
+		w.mu.Lock()
+		w.inflight[e.RequestID] = e.Request.URL
+		w.lastNetwork = time.Now()
+		w.mu.Unlock()
+	}, func(e *proto.NetworkLoadingFinished) {
+		w.requestDone(e.RequestID)
+	}, func(e *proto.NetworkLoadingFailed) {
+		w.requestDone(e.RequestID)
+	}, func(e *proto.PageFrameStartedLoading) {
+		if e.FrameID == mainFrame {
+			w.setLoading(true)
+		}
+	}, func(e *proto.PageFrameStoppedLoading) {
+		if e.FrameID == mainFrame {
+			w.setLoading(false)
+		}
+	})
+	go wait()
+
+	// Диалоги cross-origin iframe приходят в сессии фреймов, поэтому слушаем весь браузер
+	waitDialog := m.browser.Context(page.GetContext()).EachEvent(func(e *proto.PageJavascriptDialogOpening, s proto.TargetSessionID) {
+		if tabSession(s) != session {
+			return
+		}
+		// Регистрируем сами: общий обработчик диалогов мог ещё не получить событие
+		m.dialogs.open(s, e)
+		w.dialogOnce.Do(func() { close(w.dialog) })
+	})
+	go waitDialog()
+
+	// Observer ставим сразу, чтобы учесть изменения DOM, вызванные самим действием.
+	// При открытом диалоге скрипты страницы не выполняются — observer поставит snapshot.
+	if m.dialogs.get(session) == nil {
+		_, _ = page.Timeout(time.Second).Eval(settleScript)
+	}
+
+	return w
+}
+
+// dialogOpened сообщает, открылся ли на вкладке JS-диалог
+func (w *settleWatcher) dialogOpened() bool {
+	select {
+	case <-w.dialog:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *settleWatcher) requestDone(id proto.NetworkRequestID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.inflight[id]; ok {
+		delete(w.inflight, id)
+		w.lastNetwork = time.Now()
+	}
+}
+
+func (w *settleWatcher) setLoading(loading bool) {
+	w.mu.Lock()
+	w.loading = loading
+	w.mu.Unlock()
+}
+
+func (w *settleWatcher) snapshot(ctx context.Context) settleSnapshot {
+	var s settleSnapshot
+
+	res, err := w.page.Context(ctx).Timeout(time.Second).Eval(settleScript)
+	if err == nil {
+		s.DOMKnown = true
+		s.DOMQuiet = time.Duration(res.Value.Get("quiet").Int()) * time.Millisecond
+		s.Loading = res.Value.Get("ready").Str() == "loading"
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s.Loading = s.Loading || w.loading
+	s.NetworkIdle = time.Since(w.lastNetwork)
+	for _, url := range w.inflight {
+		s.Inflight = append(s.Inflight, url)
+	}
+	sort.Strings(s.Inflight)
+	return s
+}
+
+// settle ждёт, пока страница успокоится: документ загружен, незавершённых запросов не больше
+// MaxInflight и сеть простаивает NetworkIdle, DOM не меняется DOMQuiet. Ждёт не дольше MaxWait;
+// результат можно забрать через TakeSettle. Открытый JS-диалог прекращает ожидание: пока
+// на него не ответили, страница не изменится.
+func (m *Manager) settle(ctx context.Context, w *settleWatcher) types.SettleResult {
+	defer w.cancel()
+
+	cfg := m.settleConfig()
+	deadline := w.started.Add(cfg.MaxWait)
+
+	var res types.SettleResult
+	for {
+		if w.dialogOpened() {
+			res.Settled = true
+			break
+		}
+
+		pending := w.snapshot(ctx).pending(cfg)
+		if len(pending) == 0 {
+			res.Settled = true
+			break
+		}
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			res.Pending = pending
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-w.dialog:
+		case <-time.After(settlePoll):
+		}
+	}
+	res.Waited = time.Since(w.started)
+
+	if m.config.Debug {
+		m.log.Debug("Page settle", "settled", res.Settled, "waited", res.Waited.String(), "pending", strings.Join(res.Pending, "; "))
+	}
+
+	m.settleMu.Lock()
+	m.lastSettle = &res
+	m.settleMu.Unlock()
+
+	return res
+}
+
+// TakeSettle возвращает результат ожидания после последнего действия и сбрасывает его.
+// ok=false, если после предыдущего вызова действий с ожиданием не было.
+func (m *Manager) TakeSettle() (types.SettleResult, bool) {
+	m.settleMu.Lock()
+	defer m.settleMu.Unlock()
+
+	if m.lastSettle == nil {
+		return types.SettleResult{}, false
+	}
+	res := *m.lastSettle
+	m.lastSettle = nil
+	return res, true
+}
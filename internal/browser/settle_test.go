@@ -0,0 +1,69 @@
+package browser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func TestSettleSnapshotPending(t *testing.T) {
+	cfg := types.SettleConfig{
+		MaxInflight: 1,
+		NetworkIdle: 500 * time.Millisecond,
+		DOMQuiet:    300 * time.Millisecond,
+	}
+	quiet := settleSnapshot{NetworkIdle: time.Second, DOMKnown: true, DOMQuiet: time.Second}
+
+	if got := quiet.pending(cfg); len(got) != 0 {
+		t.Errorf("quiet page should be settled, got %v", got)
+	}
+
+	// Один долгий запрос в пределах MaxInflight не мешает
+	longPoll := quiet
+	longPoll.Inflight = []string{"https://example.com/poll"}
+	if got := longPoll.pending(cfg); len(got) != 0 {
+		t.Errorf("requests within MaxInflight should be ignored, got %v", got)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(s *settleSnapshot)
+		want   string
+	}{
+		{"loading", func(s *settleSnapshot) { s.Loading = true }, "navigation in progress"},
+		{"document unavailable", func(s *settleSnapshot) { s.DOMKnown = false }, "navigation in progress"},
+		{"recent network activity", func(s *settleSnapshot) { s.NetworkIdle = 100 * time.Millisecond }, "network is busy"},
+		{"dom changing", func(s *settleSnapshot) { s.DOMQuiet = 50 * time.Millisecond }, "page content is still changing"},
+		{"requests in flight", func(s *settleSnapshot) {
+			s.Inflight = []string{"https://a/1", "https://a/2", "https://a/3", "https://a/4"}
+		}, "4 network requests in flight: https://a/1, https://a/2, https://a/3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := quiet
+			tt.modify(&s)
+			got := s.pending(cfg)
+			if len(got) != 1 || !strings.HasPrefix(got[0], tt.want) {
+				t.Errorf("pending() = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettleConfigDefaults(t *testing.T) {
+	m := &Manager{config: &types.BrowserConfig{Settle: types.SettleConfig{MaxInflight: -1, MaxWait: 3 * time.Second}}}
+
+	cfg := m.settleConfig()
+	if cfg.MaxInflight != 0 {
+		t.Errorf("negative MaxInflight should become 0, got %d", cfg.MaxInflight)
+	}
+	if cfg.MaxWait != 3*time.Second {
+		t.Errorf("explicit MaxWait should be kept, got %v", cfg.MaxWait)
+	}
+	if cfg.NetworkIdle != defaultNetworkIdle || cfg.DOMQuiet != defaultDOMQuiet {
+		t.Errorf("zero durations should get defaults, got %+v", cfg)
+	}
+}
@@ -0,0 +1,326 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// tab — вкладка в реестре менеджера. ID — короткий номер для модели, не меняется,
+// пока вкладка открыта.
+type tab struct {
+	id     int
+	page   *rod.Page
+	opener int
+	popup  bool
+}
+
+// tabRegistry хранит открытые вкладки в порядке открытия. Вкладки, которые открыла
+// страница (target=_blank, window.open, OAuth), попадают сюда при синхронизации
+// и не активируются сами: переключается на них только агент.
+type tabRegistry struct {
+	mu     sync.Mutex
+	tabs   []*tab
+	nextID int
+	// opened и closed — вкладки, открытые и закрытые страницей с последнего TakeTabEvents
+	opened []int
+	closed []int
+}
+
+func (r *tabRegistry) add(page *rod.Page, opener int, popup bool) *tab {
+	r.nextID++
+	t := &tab{id: r.nextID, page: page, opener: opener, popup: popup}
+	r.tabs = append(r.tabs, t)
+	return t
+}
+
+func (r *tabRegistry) byID(id int) *tab {
+	for _, t := range r.tabs {
+		if t.id == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (r *tabRegistry) byTarget(id proto.TargetTargetID) *tab {
+	for _, t := range r.tabs {
+		if t.page.TargetID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (r *tabRegistry) remove(id int) {
+	for i, t := range r.tabs {
+		if t.id == id {
+			r.tabs = append(r.tabs[:i], r.tabs[i+1:]...)
+			return
+		}
+	}
+}
+
+// setupTab подготавливает новую вкладку: запись HAR и текущую эмуляцию
+func (m *Manager) setupTab(page *rod.Page) {
+	m.trackNetwork(page)
+	m.emulateTab(page)
+}
+
+// syncTabs сверяет реестр со списком вкладок браузера: регистрирует открытые страницей
+// и убирает закрытые. Если закрылась активная вкладка, активной становится открывшая её
+// или последняя.
+func (m *Manager) syncTabs() error {
+	list, err := proto.TargetGetTargets{}.Call(m.browser)
+	if err != nil {
+		return fmt.Errorf("list browser targets: %w", err)
+	}
+
+	m.tabs.mu.Lock()
+	// Новые вкладки настраиваются после снятия блокировки: это запросы CDP, а зависшая
+	// вкладка не должна блокировать ActiveTab и остальные вызовы
+	var added []*rod.Page
+	defer func() {
+		m.tabs.mu.Unlock()
+		for _, page := range added {
+			m.setupTab(page)
+		}
+	}()
+
+	alive := map[proto.TargetTargetID]bool{}
+	for _, info := range list.TargetInfos {
+		if info.Type != proto.TargetTargetInfoTypePage {
+			continue
+		}
+		// Инкогнито-контекст видит только свои вкладки, а не вкладки соседних контекстов пула
+		if ctxID := m.browser.BrowserContextID; ctxID != "" && info.BrowserContextID != ctxID {
+			continue
+		}
+		alive[info.TargetID] = true
+
+		if m.tabs.byTarget(info.TargetID) != nil {
+			continue
+		}
+
+		page, err := m.browser.PageFromTarget(info.TargetID)
+		if err != nil {
+			continue
+		}
+		opener := 0
+		if o := m.tabs.byTarget(info.OpenerID); o != nil {
+			opener = o.id
+		}
+		t := m.tabs.add(page, opener, true)
+		m.tabs.opened = append(m.tabs.opened, t.id)
+		added = append(added, page)
+
+		if m.config.Debug {
+			m.log.Debug("New tab opened by page", "tab", t.id, "url", info.URL, "opener", opener)
+		}
+	}
+
+	activeClosed := false
+	for _, t := range append([]*tab(nil), m.tabs.tabs...) {
+		if alive[t.page.TargetID] {
+			continue
+		}
+		if t.page == m.page {
+			activeClosed = true
+		}
+		m.tabs.remove(t.id)
+		m.tabs.closed = append(m.tabs.closed, t.id)
+		m.dialogs.close(t.page.SessionID)
+	}
+
+	if activeClosed && len(m.tabs.tabs) > 0 {
+		next := m.tabs.tabs[len(m.tabs.tabs)-1]
+		m.page = next.page
+		if m.config.Debug {
+			m.log.Debug("Active tab closed, switched", "tab", next.id)
+		}
+	}
+
+	return nil
+}
+
+// ListTabs возвращает открытые вкладки
+func (m *Manager) ListTabs(ctx context.Context) ([]types.TabInfo, error) {
+	if err := m.syncTabs(); err != nil {
+		return nil, err
+	}
+
+	m.tabs.mu.Lock()
+	defer m.tabs.mu.Unlock()
+
+	tabs := make([]types.TabInfo, 0, len(m.tabs.tabs))
+	for _, t := range m.tabs.tabs {
+		tabs = append(tabs, m.tabInfo(t))
+	}
+	return tabs, nil
+}
+
+// ActiveTab возвращает активную вкладку и общее число открытых вкладок
+func (m *Manager) ActiveTab() (types.TabInfo, int) {
+	m.tabs.mu.Lock()
+	defer m.tabs.mu.Unlock()
+
+	for _, t := range m.tabs.tabs {
+		if t.page == m.page {
+			return m.tabInfo(t), len(m.tabs.tabs)
+		}
+	}
+	return types.TabInfo{}, len(m.tabs.tabs)
+}
+
+// TakeTabEvents возвращает вкладки, которые страница открыла, и ID вкладок, которые
+// закрылись сами (например, OAuth-окно после входа), с прошлого вызова
+func (m *Manager) TakeTabEvents() (opened []types.TabInfo, closed []int) {
+	if err := m.syncTabs(); err != nil && m.config.Debug {
+		m.log.Debug("Tab sync failed", "error", err)
+	}
+
+	m.tabs.mu.Lock()
+	defer m.tabs.mu.Unlock()
+
+	for _, id := range m.tabs.opened {
+		if t := m.tabs.byID(id); t != nil {
+			opened = append(opened, m.tabInfo(t))
+		}
+	}
+	closed = m.tabs.closed
+	m.tabs.opened = nil
+	m.tabs.closed = nil
+	return opened, closed
+}
+
+// SwitchTab делает вкладку активной: последующие действия и extract_page работают в ней
+func (m *Manager) SwitchTab(ctx context.Context, id int) error {
+	if err := m.syncTabs(); err != nil {
+		return err
+	}
+
+	m.tabs.mu.Lock()
+	t := m.tabs.byID(id)
+	m.tabs.mu.Unlock()
+	if t == nil {
+		return fmt.Errorf("tab [%d] not found. Call list_tabs to see open tabs", id)
+	}
+
+	if _, err := t.page.Activate(); err != nil {
+		return fmt.Errorf("activate tab [%d]: %w", id, err)
+	}
+	m.tabs.mu.Lock()
+	m.page = t.page
+	m.tabs.mu.Unlock()
+
+	if m.config.Debug {
+		m.log.Debug("Switched tab", "tab", id)
+	}
+	return nil
+}
+
+// OpenTab открывает URL в новой вкладке и делает её активной. Если URL не открылся,
+// вкладка остаётся открытой и активной: её описание возвращается вместе с ошибкой.
+func (m *Manager) OpenTab(ctx context.Context, url string) (types.TabInfo, error) {
+	if err := m.syncTabs(); err != nil {
+		return types.TabInfo{}, err
+	}
+
+	page, err := m.browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		return types.TabInfo{}, fmt.Errorf("open tab: %w", err)
+	}
+
+	m.tabs.mu.Lock()
+	opener := 0
+	for _, t := range m.tabs.tabs {
+		if t.page == m.page {
+			opener = t.id
+		}
+	}
+	t := m.tabs.add(page, opener, false)
+	m.page = page
+	m.tabs.mu.Unlock()
+	m.setupTab(page)
+
+	var navErr error
+	if url != "" {
+		navErr = m.Navigate(ctx, url)
+	}
+
+	m.tabs.mu.Lock()
+	defer m.tabs.mu.Unlock()
+	return m.tabInfo(t), navErr
+}
+
+// CloseTab закрывает вкладку. Последнюю вкладку закрыть нельзя. При закрытии активной
+// активной становится открывшая её вкладка, если она ещё открыта, иначе последняя.
+func (m *Manager) CloseTab(ctx context.Context, id int) (types.TabInfo, error) {
+	if err := m.syncTabs(); err != nil {
+		return types.TabInfo{}, err
+	}
+
+	m.tabs.mu.Lock()
+	t := m.tabs.byID(id)
+	count := len(m.tabs.tabs)
+	m.tabs.mu.Unlock()
+
+	if t == nil {
+		return types.TabInfo{}, fmt.Errorf("tab [%d] not found. Call list_tabs to see open tabs", id)
+	}
+	if count == 1 {
+		return types.TabInfo{}, fmt.Errorf("cannot close the last tab")
+	}
+
+	if err := t.page.Close(); err != nil {
+		return types.TabInfo{}, fmt.Errorf("close tab [%d]: %w", id, err)
+	}
+
+	m.tabs.mu.Lock()
+	defer m.tabs.mu.Unlock()
+
+	m.tabs.remove(id)
+	m.dialogs.close(t.page.SessionID)
+	if t.page == m.page {
+		next := m.tabs.byID(t.opener)
+		if next == nil {
+			next = m.tabs.tabs[len(m.tabs.tabs)-1]
+		}
+		m.page = next.page
+		_, _ = next.page.Activate()
+	}
+
+	for _, other := range m.tabs.tabs {
+		if other.page == m.page {
+			return m.tabInfo(other), nil
+		}
+	}
+	return types.TabInfo{}, nil
+}
+
+// activePage возвращает активную вкладку. m.page меняют переключение и закрытие вкладок,
+// поэтому вне реестра он читается только через activePage.
+func (m *Manager) activePage() *rod.Page {
+	m.tabs.mu.Lock()
+	defer m.tabs.mu.Unlock()
+	return m.page
+}
+
+// tabInfo собирает описание вкладки. Вызывается под m.tabs.mu.
+func (m *Manager) tabInfo(t *tab) types.TabInfo {
+	info := types.TabInfo{
+		ID:       t.id,
+		Active:   t.page == m.page,
+		OpenerID: t.opener,
+		Popup:    t.popup,
+	}
+	if pi, err := t.page.Info(); err == nil {
+		info.Title = pi.Title
+		info.URL = pi.URL
+	}
+	return info
+}
@@ -0,0 +1,33 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestTabRegistry(t *testing.T) {
+	var r tabRegistry
+
+	first := r.add(&rod.Page{TargetID: "A"}, 0, false)
+	second := r.add(&rod.Page{TargetID: "B"}, first.id, true)
+	third := r.add(&rod.Page{TargetID: "C"}, 0, false)
+
+	if first.id != 1 || second.id != 2 || third.id != 3 {
+		t.Fatalf("expected sequential IDs starting at 1, got %d %d %d", first.id, second.id, third.id)
+	}
+	if got := r.byTarget(proto.TargetTargetID("B")); got != second {
+		t.Errorf("byTarget returned %+v", got)
+	}
+
+	r.remove(second.id)
+	if r.byID(second.id) != nil || len(r.tabs) != 2 {
+		t.Errorf("tab should be removed, got %d tabs", len(r.tabs))
+	}
+
+	// ID закрытой вкладки не переиспользуется
+	if fourth := r.add(&rod.Page{TargetID: "D"}, 0, false); fourth.id != 4 {
+		t.Errorf("expected ID 4 for a new tab, got %d", fourth.id)
+	}
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stannisl/ai-browser-assistant/internal/schema"
+)
+
+// WriteFile сохраняет структурированный результат задачи. Формат выбирается по расширению:
+// .csv — таблица, всё остальное — JSON с отступами.
+func WriteFile(path string, data, outputSchema json.RawMessage) error {
+	var content []byte
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		content, err = ToCSV(data, schema.PropertyOrder(outputSchema))
+	default:
+		var buf bytes.Buffer
+		err = json.Indent(&buf, data, "", "  ")
+		buf.WriteByte('\n')
+		content = buf.Bytes()
+	}
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ToCSV превращает массив объектов в CSV. Объект с единственным свойством-массивом
+// разворачивается, одиночный объект становится одной строкой. Колонки идут в порядке
+// columns, остальные ключи — по алфавиту. Вложенные значения записываются как JSON.
+func ToCSV(data json.RawMessage, columns []string) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if obj, ok := v.(map[string]interface{}); ok && len(obj) == 1 {
+		for _, inner := range obj {
+			if list, ok := inner.([]interface{}); ok {
+				v = list
+			}
+		}
+	}
+
+	var records []map[string]interface{}
+	switch val := v.(type) {
+	case []interface{}:
+		for i, item := range val {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("item %d is not an object", i)
+			}
+			records = append(records, obj)
+		}
+	case map[string]interface{}:
+		records = append(records, val)
+	default:
+		return nil, fmt.Errorf("expected an array of objects or an object")
+	}
+
+	header := append([]string{}, columns...)
+	known := map[string]bool{}
+	for _, c := range columns {
+		known[c] = true
+	}
+	var extra []string
+	for _, r := range records {
+		for k := range r {
+			if !known[k] {
+				known[k] = true
+				extra = append(extra, k)
+			}
+		}
+	}
+	sort.Strings(extra)
+	header = append(header, extra...)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		row := make([]string, len(header))
+		for i, col := range header {
+			row[i] = cellString(r[col])
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+func cellString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64, bool:
+		return fmt.Sprint(val)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		columns []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "array with schema order",
+			data:    `[{"url": "https://a", "title": "Go", "salary": 100}, {"title": "Rust, senior", "url": "https://b"}]`,
+			columns: []string{"title", "salary", "url"},
+			want:    "title,salary,url\nGo,100,https://a\n\"Rust, senior\",,https://b\n",
+		},
+		{
+			name: "wrapped array and extra keys",
+			data: `{"emails": [{"from": "Amazon", "tags": ["a", "b"]}]}`,
+			want: "from,tags\nAmazon,\"[\"\"a\"\",\"\"b\"\"]\"\n",
+		},
+		{
+			name: "single object",
+			data: `{"total": 3, "ok": true}`,
+			want: "ok,total\ntrue,3\n",
+		},
+		{
+			name:    "array of scalars",
+			data:    `[1, 2]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToCSV(json.RawMessage(tt.data), tt.columns)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	data := json.RawMessage(`[{"title":"Go"}]`)
+
+	jsonPath := filepath.Join(dir, "result.json")
+	if err := WriteFile(jsonPath, data, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(jsonPath)
+	if string(content) != "[\n  {\n    \"title\": \"Go\"\n  }\n]\n" {
+		t.Errorf("unexpected JSON output: %q", content)
+	}
+
+	csvPath := filepath.Join(dir, "result.csv")
+	if err := WriteFile(csvPath, data, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(csvPath)
+	if string(content) != "title\nGo\n" {
+		t.Errorf("unexpected CSV output: %q", content)
+	}
+}
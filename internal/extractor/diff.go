@@ -0,0 +1,162 @@
+package extractor
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// Diff сравнивает два состояния одной страницы. Элементы сопоставляются по ID,
+// которые стабильны между извлечениями, контент — построчно.
+func Diff(prev, cur *types.PageState) *types.PageDiff {
+	diff := &types.PageDiff{
+		ModalOpened: !prev.HasModal && cur.HasModal,
+		ModalClosed: prev.HasModal && !cur.HasModal,
+	}
+
+	prevByID := make(map[int]types.PageElement, len(prev.Elements))
+	for _, el := range prev.Elements {
+		prevByID[el.ID] = el
+	}
+
+	curIDs := make(map[int]bool, len(cur.Elements))
+	for _, el := range cur.Elements {
+		curIDs[el.ID] = true
+
+		before, ok := prevByID[el.ID]
+		if !ok {
+			diff.Added = append(diff.Added, el)
+			continue
+		}
+		if elementChanged(before, el) {
+			diff.Changed = append(diff.Changed, types.ElementChange{Before: before, After: el})
+		}
+	}
+
+	for _, el := range prev.Elements {
+		if !curIDs[el.ID] {
+			diff.Removed = append(diff.Removed, el)
+		}
+	}
+
+	diff.ContentAdded, diff.ContentRemoved = diffLines(prev.Content, cur.Content)
+
+	return diff
+}
+
+func elementChanged(a, b types.PageElement) bool {
+	return a.Tag != b.Tag || a.Text != b.Text || !maps.Equal(a.Attributes, b.Attributes) ||
+		a.Value != b.Value || !slices.Equal(a.Options, b.Options) || !equalChecked(a.Checked, b.Checked)
+}
+
+func equalChecked(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// diffLines возвращает строки, появившиеся в cur, и строки, пропавшие из prev
+func diffLines(prev, cur string) (added, removed []string) {
+	prevLines := splitLines(prev)
+	curLines := splitLines(cur)
+
+	prevSet := make(map[string]bool, len(prevLines))
+	for _, line := range prevLines {
+		prevSet[line] = true
+	}
+	curSet := make(map[string]bool, len(curLines))
+	for _, line := range curLines {
+		curSet[line] = true
+	}
+
+	for _, line := range curLines {
+		if !prevSet[line] {
+			added = append(added, line)
+		}
+	}
+	for _, line := range prevLines {
+		if !curSet[line] {
+			removed = append(removed, line)
+		}
+	}
+
+	return added, removed
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func (e *Extractor) FormatDiffForLLM(state *types.PageState, diff *types.PageDiff) string {
+	var b strings.Builder
+
+	writeHeader(&b, state)
+	b.WriteString("\n")
+
+	if diff.IsEmpty() {
+		b.WriteString("No changes since the last extract_page.\n")
+		return b.String()
+	}
+
+	b.WriteString("### Changes since the last extract_page\n")
+
+	if diff.ModalOpened {
+		b.WriteString("⚠️ **MODAL/POPUP OPENED** - Close it first with Escape or find close button.\n")
+	}
+	if diff.ModalClosed {
+		b.WriteString("Modal/popup closed.\n")
+	}
+
+	if len(diff.Added) > 0 {
+		b.WriteString(fmt.Sprintf("\nAdded elements (%d):\n", len(diff.Added)))
+		for _, el := range diff.Added {
+			b.WriteString("+ " + e.formatElement(el) + "\n")
+		}
+	}
+
+	if len(diff.Removed) > 0 {
+		b.WriteString(fmt.Sprintf("\nRemoved elements (%d):\n", len(diff.Removed)))
+		for _, el := range diff.Removed {
+			b.WriteString("- " + e.formatElement(el) + "\n")
+		}
+	}
+
+	if len(diff.Changed) > 0 {
+		b.WriteString(fmt.Sprintf("\nChanged elements (%d):\n", len(diff.Changed)))
+		for _, ch := range diff.Changed {
+			line := "~ " + e.formatElement(ch.After)
+			if ch.Before.Text != ch.After.Text {
+				line += fmt.Sprintf(" (was %q)", ch.Before.Text)
+			}
+			if ch.Before.Value != ch.After.Value {
+				line += fmt.Sprintf(" (value was %q)", ch.Before.Value)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if len(diff.ContentAdded) > 0 || len(diff.ContentRemoved) > 0 {
+		b.WriteString("\nContent changes:\n```\n")
+		for _, line := range diff.ContentAdded {
+			b.WriteString("+ " + line + "\n")
+		}
+		for _, line := range diff.ContentRemoved {
+			b.WriteString("- " + line + "\n")
+		}
+		b.WriteString("```\n")
+	}
+
+	b.WriteString(fmt.Sprintf("\nTotal interactive elements: %d. Call extract_page with full=true for the complete list.\n", state.ElementCount))
+
+	return b.String()
+}
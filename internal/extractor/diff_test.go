@@ -0,0 +1,120 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func TestDiff(t *testing.T) {
+	prev := &types.PageState{
+		URL: "https://example.com",
+		Elements: []types.PageElement{
+			{ID: 0, Tag: "input", Text: "", Attributes: map[string]string{"type": "text"}},
+			{ID: 1, Tag: "button", Text: "Search"},
+			{ID: 2, Tag: "a", Text: "Old link"},
+		},
+		Content: "first line\nsecond line",
+	}
+	cur := &types.PageState{
+		URL: "https://example.com",
+		Elements: []types.PageElement{
+			{ID: 0, Tag: "input", Text: "golang", Attributes: map[string]string{"type": "text"}},
+			{ID: 1, Tag: "button", Text: "Search"},
+			{ID: 3, Tag: "a", Text: "New link"},
+		},
+		Content:  "first line\nthird line",
+		HasModal: true,
+	}
+
+	diff := Diff(prev, cur)
+
+	if len(diff.Added) != 1 || diff.Added[0].ID != 3 {
+		t.Errorf("expected element 3 added, got %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != 2 {
+		t.Errorf("expected element 2 removed, got %v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].After.Text != "golang" {
+		t.Errorf("expected element 0 changed, got %v", diff.Changed)
+	}
+	if len(diff.ContentAdded) != 1 || diff.ContentAdded[0] != "third line" {
+		t.Errorf("expected 'third line' added, got %v", diff.ContentAdded)
+	}
+	if len(diff.ContentRemoved) != 1 || diff.ContentRemoved[0] != "second line" {
+		t.Errorf("expected 'second line' removed, got %v", diff.ContentRemoved)
+	}
+	if !diff.ModalOpened || diff.ModalClosed {
+		t.Error("expected modal opened")
+	}
+	if diff.Size() != 3 {
+		t.Errorf("expected size 3, got %d", diff.Size())
+	}
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	state := &types.PageState{
+		URL:      "https://example.com",
+		Elements: []types.PageElement{{ID: 0, Tag: "button", Text: "OK"}},
+		Content:  "text",
+	}
+
+	diff := Diff(state, state)
+
+	if !diff.IsEmpty() {
+		t.Errorf("expected empty diff, got %+v", diff)
+	}
+}
+
+func TestFormatDiffForLLM(t *testing.T) {
+	e := New(nil, nil)
+	state := &types.PageState{Title: "Test", URL: "https://example.com", ElementCount: 1}
+
+	out := e.FormatDiffForLLM(state, &types.PageDiff{})
+	if out != "## Page: Test\n## URL: https://example.com\n\nNo changes since the last extract_page.\n" {
+		t.Errorf("unexpected output for empty diff: %q", out)
+	}
+
+	out = e.FormatDiffForLLM(state, &types.PageDiff{
+		Changed: []types.ElementChange{{
+			Before: types.PageElement{ID: 4, Tag: "input", Text: ""},
+			After:  types.PageElement{ID: 4, Tag: "input", Text: "hello"},
+		}},
+	})
+	want := `~ [4] input "hello" (was "")`
+	if !strings.Contains(out, want) {
+		t.Errorf("expected output to contain %q, got %q", want, out)
+	}
+}
+
+func TestDiff_CheckedState(t *testing.T) {
+	unchecked, checked := false, true
+	prev := &types.PageState{Elements: []types.PageElement{{ID: 0, Tag: "input", Text: "Remember me", Checked: &unchecked}}}
+	cur := &types.PageState{Elements: []types.PageElement{{ID: 0, Tag: "input", Text: "Remember me", Checked: &checked}}}
+
+	if diff := Diff(prev, cur); len(diff.Changed) != 1 {
+		t.Errorf("expected checkbox toggle to be a change, got %+v", diff)
+	}
+}
+
+func TestDiff_FormValues(t *testing.T) {
+	prev := &types.PageState{Elements: []types.PageElement{
+		{ID: 0, Tag: "input", Text: "Email"},
+		{ID: 1, Tag: "select", Text: "Country", Value: "Russia", Options: []types.SelectOption{{Value: "ru", Label: "Russia", Selected: true}, {Value: "kz", Label: "Kazakhstan"}}},
+	}}
+	cur := &types.PageState{Elements: []types.PageElement{
+		{ID: 0, Tag: "input", Text: "Email", Value: "ann@example.com"},
+		{ID: 1, Tag: "select", Text: "Country", Value: "Russia", Options: []types.SelectOption{{Value: "ru", Label: "Russia", Selected: true}}},
+	}}
+
+	diff := Diff(prev, cur)
+	if len(diff.Changed) != 2 {
+		t.Fatalf("expected typed value and option list to be changes, got %+v", diff)
+	}
+
+	out := New(nil, nil).FormatDiffForLLM(cur, diff)
+	if !strings.Contains(out, `(value was "")`) {
+		t.Errorf("expected previous value in output, got %q", out)
+	}
+}
@@ -8,19 +8,30 @@ import (
 	"time"
 
 	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stannisl/ai-browser-assistant/internal/browser"
 	"github.com/stannisl/ai-browser-assistant/internal/logger"
+	"github.com/stannisl/ai-browser-assistant/internal/profiles"
 	"github.com/stannisl/ai-browser-assistant/internal/types"
 )
 
 type Extractor struct {
-	page   *rod.Page
-	logger *logger.Logger
+	page     *rod.Page
+	logger   *logger.Logger
+	profiles *profiles.Registry
+
+	// last — результат предыдущего Extract, с ним сравнивается следующее извлечение
+	last *types.PageState
+	// nextIDs — следующий свободный ID элемента для каждой вкладки. Счётчик не сбрасывается
+	// при перезагрузке документа: старый ID не должен указать на другой элемент.
+	nextIDs map[proto.TargetTargetID]int
 }
 
 func New(page *rod.Page, log *logger.Logger) *Extractor {
 	return &Extractor{
-		page:   page,
-		logger: log,
+		page:    page,
+		logger:  log,
+		nextIDs: map[proto.TargetTargetID]int{},
 	}
 }
 
@@ -28,6 +39,431 @@ func (e *Extractor) UpdatePage(page *rod.Page) {
 	e.page = page
 }
 
+// SetProfiles задаёт профили сайтов, правила которых применяются при извлечении
+func (e *Extractor) SetProfiles(registry *profiles.Registry) {
+	e.profiles = registry
+}
+
+// MatchProfiles возвращает профили сайтов, подходящие к URL
+func (e *Extractor) MatchProfiles(url string) []*profiles.Profile {
+	return e.profiles.Match(url)
+}
+
+// LastState возвращает состояние, полученное предыдущим вызовом Extract
+func (e *Extractor) LastState() *types.PageState {
+	return e.last
+}
+
+// Reset забывает предыдущее состояние, следующее извлечение будет полным
+func (e *Extractor) Reset() {
+	e.last = nil
+}
+
+// extractScript извлекает интерактивные элементы и текстовый контент одного фрейма.
+// Обходит открытые shadow root, элементы регистрируются в window._ai_elements под глобальными ID.
+//
+// Реестр живёт между извлечениями: пока элемент остаётся в DOM, он сохраняет свой ID.
+// Для каждого ID запоминается отпечаток (тег, текст, атрибуты, позиция), по которому
+// window._ai_resolve находит перерисованный элемент вместо того, чтобы вернуть ошибку.
+//
+// Особенности сайтов (свои чекбоксы, строки списков, блоки контента) приходят в args.site
+// из профилей, подходящих к URL страницы.
+const extractScript = `(args) => {
+	// Новый документ — новый реестр, но не новая нумерация: счётчик ID вкладки ведёт Go
+	// и передаёт в args.next, поэтому ID элементов прежнего документа не достаются новым.
+	// window._ai_next страхует от счётчика, начатого заново другим процессом агента.
+	const fresh = !window._ai_ids;
+	if (fresh) {
+		window._ai_ids = new WeakMap();
+		window._ai_elements = [];
+		window._ai_fingerprints = {};
+	}
+	let next = args.top && !fresh ? Math.max(args.next, window._ai_next || 0) : args.next;
+	const results = [];
+	let count = 0;
+
+	// Корни поиска: документ и все открытые shadow root
+	const collectRoots = (root, roots) => {
+		roots.push(root);
+		root.querySelectorAll('*').forEach(el => {
+			if (el.shadowRoot) collectRoots(el.shadowRoot, roots);
+		});
+		return roots;
+	};
+	const roots = collectRoots(document, []);
+
+	// Правила профилей сайта. Селекторы пользовательские, поэтому ошибки в них не должны ронять извлечение
+	const site = args.site;
+	const matchesAny = (el, sels) => sels.some(sel => { try { return el.matches(sel); } catch (e) { return false; } });
+	const closestAny = (el, sels) => {
+		for (const sel of sels) {
+			try {
+				const found = el.closest(sel);
+				if (found) return found;
+			} catch (e) {}
+		}
+		return null;
+	};
+
+	const fingerprint = (el) => {
+		const rect = el.getBoundingClientRect();
+		return {
+			tag: el.tagName.toLowerCase(),
+			text: (el.innerText || el.value || el.getAttribute('aria-label') || '').trim().replace(/\s+/g, ' ').substring(0, 100),
+			elId: el.id || '',
+			name: el.getAttribute('name') || '',
+			type: el.getAttribute('type') || '',
+			role: el.getAttribute('role') || '',
+			href: el.getAttribute('href') || '',
+			title: el.getAttribute('title') || '',
+			x: Math.round(rect.left + window.scrollX),
+			y: Math.round(rect.top + window.scrollY)
+		};
+	};
+
+	// Уникальный CSS-селектор элемента внутри его документа или shadow root
+	const uniqueID = (el) => el.id && el.getRootNode().querySelectorAll('#' + CSS.escape(el.id)).length === 1;
+	const cssPath = (el) => {
+		const parts = [];
+		let node = el;
+		while (node && node.nodeType === 1) {
+			if (uniqueID(node)) {
+				parts.unshift('#' + CSS.escape(node.id));
+				break;
+			}
+			let part = node.tagName.toLowerCase();
+			const parent = node.parentElement;
+			if (parent) {
+				const same = Array.from(parent.children).filter(c => c.tagName === node.tagName);
+				if (same.length > 1) part += ':nth-of-type(' + (same.indexOf(node) + 1) + ')';
+			}
+			parts.unshift(part);
+			node = parent;
+		}
+		return parts.join(' > ');
+	};
+
+	// Состояние элементов формы: значение, отметка, варианты select, границы range и дат
+	const controlState = (el, tag) => {
+		if (tag === 'select') {
+			const label = (o) => (o.label || o.text || '').trim().replace(/\s+/g, ' ').substring(0, 60);
+			return {
+				value: Array.from(el.selectedOptions).map(label).join(', '),
+				options: Array.from(el.options).slice(0, 50).map(o => ({value: o.value, label: label(o), selected: o.selected, disabled: o.disabled})),
+				optionCount: el.options.length
+			};
+		}
+		if (tag === 'input' && (el.type === 'checkbox' || el.type === 'radio')) {
+			return {checked: el.checked};
+		}
+		if (tag === 'input' || tag === 'textarea') {
+			return {
+				value: el.type === 'password' ? (el.value ? '***' : '') : (el.value || ''),
+				min: el.getAttribute('min') || '',
+				max: el.getAttribute('max') || '',
+				step: el.getAttribute('step') || ''
+			};
+		}
+		const aria = el.getAttribute('aria-checked');
+		if (aria === 'true' || aria === 'false') return {checked: aria === 'true'};
+		return {};
+	};
+
+	const idOf = (el) => {
+		const id = window._ai_ids.get(el);
+		return (id !== undefined && window._ai_elements[id] === el) ? id : -1;
+	};
+
+	// Находит элемент по ID; если он выпал из DOM — ищет самый похожий по отпечатку
+	window._ai_resolve = (id) => {
+		const el = window._ai_elements[id];
+		if (el && el.isConnected) return el;
+
+		const fp = window._ai_fingerprints[id];
+		if (!fp) return null;
+
+		let best = null;
+		let bestScore = 0;
+		collectRoots(document, []).forEach(root => root.querySelectorAll(fp.tag).forEach(candidate => {
+			const current = window._ai_ids.get(candidate);
+			if (current !== undefined && current !== id && window._ai_elements[current] === candidate) return;
+
+			const c = fingerprint(candidate);
+			let score = 0;
+			if (fp.elId && c.elId === fp.elId) score += 3;
+			if (fp.name && c.name === fp.name) score += 2;
+			if (fp.text && c.text === fp.text) score += 2;
+			if (fp.href && c.href === fp.href) score += 1;
+			if (fp.title && c.title === fp.title) score += 1;
+			if (c.type === fp.type && c.role === fp.role) score += 1;
+			if (Math.abs(c.x - fp.x) < 50 && Math.abs(c.y - fp.y) < 50) score += 1;
+			if (score > bestScore) {
+				best = candidate;
+				bestScore = score;
+			}
+		}));
+
+		if (!best || bestScore < 3) return null;
+
+		window._ai_elements[id] = best;
+		window._ai_ids.set(best, id);
+		return best;
+	};
+	
+	// === 1. ИНТЕРАКТИВНЫЕ ЭЛЕМЕНТЫ ===
+	const selectors = [
+		'a[href]',
+		'button',
+		'input', // Убрали not(hidden), проверим видимость в коде
+		'textarea',
+		'select',
+		'[role="button"]',
+		'[role="checkbox"]',
+		'[role="link"]',
+		'[role="menuitem"]',
+		'[role="tab"]',
+		'[onclick]',
+		'[title]', 
+		'[data-title-shortcut]',
+		'.checkbox__box',                // General UI checkbox
+		'.checkbox__control',
+		'[class*="button"]',
+		'[class*="btn"]',
+		'label',
+		...site.selectors
+	];
+	
+	const seen = new Set();
+	
+	roots.forEach(root => selectors.forEach(sel => {
+		try {
+			root.querySelectorAll(sel).forEach(el => {
+				if (el === document.body || el === document.documentElement) return;
+				if (seen.has(el)) return;
+				
+				const rect = el.getBoundingClientRect();
+				const style = window.getComputedStyle(el);
+				
+				// ХИТРАЯ ПРОВЕРКА ВИДИМОСТИ
+				// Некоторые чекбоксы (input) имеют opacity 0, но лежат поверх видимого элемента
+				let isVisible = 
+					rect.width > 0 && 
+					rect.height > 0 && 
+					style.display !== 'none' && 
+					style.visibility !== 'hidden';
+
+				// Если это не input, требуем непрозрачность
+				if (el.tagName.toLowerCase() !== 'input' && !el.classList.contains('checkbox__control')) {
+					if (parseFloat(style.opacity) < 0.1) isVisible = false;
+				}
+				
+				if (!isVisible) return;
+
+				seen.add(el);
+				
+				// === ИЗВЛЕЧЕНИЕ ТЕКСТА ===
+				let text = '';
+				
+				// 1. Значения полей. Пароли не отдаём в контекст модели
+				if (el.type === 'checkbox' || el.type === 'radio') {
+					// value у чекбоксов служебный ("on"), подписью служит label
+					text = Array.from(el.labels || []).map(l => l.innerText).join(' ');
+				} else if (el.tagName.toLowerCase() === 'input' || el.tagName.toLowerCase() === 'textarea') {
+					text = (el.type === 'password' ? '' : el.value) || el.placeholder || '';
+				} else if (el.tagName.toLowerCase() === 'select') {
+					text = Array.from(el.selectedOptions).map(o => o.label || o.text).join(', ');
+				} 
+				// 2. Текст внутри
+				else {
+					text = el.innerText || el.textContent || '';
+				}
+
+				// 3. Атрибуты (title, aria)
+				if (!text.trim()) {
+					text = el.getAttribute('title') || 
+						   el.getAttribute('aria-label') || 
+						   el.getAttribute('data-title-shortcut') || '';
+				}
+
+				// 4. ЕСЛИ ЭТО ЧЕКБОКС БЕЗ ТЕКСТА (ВАЖНО!)
+				// Пытаемся найти тему письма рядом, чтобы ЛЛМ поняла "Чекбокс для письма X"
+				const isCheckbox = el.getAttribute('role') === 'checkbox' || 
+								   matchesAny(el, site.checkboxes) ||
+								   el.type === 'checkbox';
+				
+				if (isCheckbox && !text) {
+					// Ищем родительскую строку таблицы/списка
+					const row = closestAny(el, [...site.rows, '[role="row"]']);
+					if (row) {
+						// Ищем тему или отправителя в этой строке
+						const subject = site.rowLabels.map(sel => { try { return row.querySelector(sel); } catch (e) { return null; } }).find(Boolean);
+						if (subject) text = "Выбрать: " + subject.innerText;
+						else text = "Чекбокс выбора";
+					} else {
+						text = "Чекбокс";
+					}
+				}
+				
+				text = text.trim().replace(/\s+/g, ' ').substring(0, 150);
+				
+				// Фильтрация мусора (пустые span/div без роли)
+				const tag = el.tagName.toLowerCase();
+				if (!text && !['input', 'select', 'textarea', 'button'].includes(tag) && !isCheckbox) {
+					// Если нет текста и это не кнопка/инпут - пропускаем, если нет вложенного SVG с title
+					const svgTitle = el.querySelector('svg title');
+					if (svgTitle) text = svgTitle.textContent.trim();
+					else return; 
+				}
+				
+				// Определяем тип для ЛЛМ
+				let role = el.getAttribute('role') || '';
+				if (isCheckbox) role = 'checkbox';
+				
+				// Элемент, уже известный реестру, сохраняет свой ID
+				let id = window._ai_ids.get(el);
+				if (id === undefined || window._ai_elements[id] !== el) {
+					id = next++;
+					window._ai_ids.set(el, id);
+					window._ai_elements[id] = el;
+				}
+				window._ai_fingerprints[id] = fingerprint(el);
+				count++;
+				
+				const clickable =
+					['a', 'button', 'select', 'summary', 'label'].includes(tag) ||
+					['button', 'link', 'menuitem', 'tab', 'checkbox'].includes(role) ||
+					['checkbox', 'radio', 'submit', 'button', 'reset'].includes(el.type) ||
+					el.hasAttribute('onclick') ||
+					style.cursor === 'pointer';
+				
+				const control = controlState(el, tag);
+				results.push({
+					id: id,
+					tag: tag,
+					text: text,
+					type: el.type || '',
+					href: tag === 'a' ? (el.href || '') : '',
+					title: el.getAttribute('title') || '',
+					role: role,
+					domId: el.id || '',
+					name: el.getAttribute('name') || '',
+					placeholder: el.getAttribute('placeholder') || '',
+					ariaLabel: el.getAttribute('aria-label') || '',
+					rel: el.getAttribute('rel') || '',
+					required: !!el.required,
+					selector: cssPath(el),
+					clickable: clickable,
+					rect: {x: rect.left, y: rect.top, width: rect.width, height: rect.height},
+					// Маркер, что это похоже на чекбокс
+					isCheckbox: isCheckbox,
+					value: control.value,
+					checked: control.checked,
+					options: control.options,
+					optionCount: control.optionCount || 0,
+					min: control.min || '',
+					max: control.max || '',
+					step: control.step || ''
+				});
+			});
+		} catch (e) {}
+	}));
+	
+	// === ТЕКСТОВЫЙ КОНТЕНТ (Остался прежним) ===
+	let pageContent = [];
+	let listItems = [];
+	
+	// Строки списков: сначала шаблоны профиля сайта, потом общий role="row"
+	const rowPatterns = [...site.rows, '[role="row"]'];
+	for (const pattern of rowPatterns) {
+		let items = [];
+		try { items = document.querySelectorAll(pattern); } catch (e) {}
+		if (items.length > 0) {
+			items.forEach((item, idx) => {
+				if (idx < 15) {
+					const t = item.innerText.replace(/\s+/g, ' ').substring(0, 200);
+					if(t.length > 10) listItems.push({index: idx+1, content: t});
+				}
+			});
+			if(listItems.length) break;
+		}
+	}
+	
+	// Fallback content
+	if (!listItems.length) {
+		const blocks = ['h1', 'h2', '.article', ...site.blocks].join(', ');
+		let found = [];
+		try { found = document.querySelectorAll(blocks); } catch (e) { found = document.querySelectorAll('h1, h2, .article'); }
+		found.forEach(el => {
+			const t = el.innerText.replace(/\s+/g, ' ');
+			if(t.length > 20) pageContent.push(t.substring(0,500));
+		});
+	}
+
+	let hasModal = roots.some(root => !!root.querySelector('[role="dialog"], .modal'));
+
+	// === ФОРМЫ: поля, обязательность и кнопка отправки одной группой ===
+	const forms = [];
+	roots.forEach(root => root.querySelectorAll('form').forEach(form => {
+		const rect = form.getBoundingClientRect();
+		if (rect.width === 0 || rect.height === 0) return;
+
+		const inputs = [];
+		form.querySelectorAll('input, textarea, select').forEach(input => {
+			const type = (input.type || input.tagName).toLowerCase();
+			if (['hidden', 'submit', 'button', 'reset', 'image'].includes(type)) return;
+			inputs.push({
+				elementId: idOf(input),
+				id: input.id || '',
+				name: input.getAttribute('name') || '',
+				type: type,
+				selector: cssPath(input),
+				required: !!input.required,
+				placeholder: input.getAttribute('placeholder') || '',
+				// Пароли не отдаём в контекст модели
+				value: type === 'password' ? (input.value ? '***' : '') : (input.value || '')
+			});
+		});
+
+		let submit = null;
+		const btn = form.querySelector('button[type="submit"], input[type="submit"], button:not([type])');
+		if (btn) {
+			const r = btn.getBoundingClientRect();
+			submit = {
+				elementId: idOf(btn),
+				id: btn.id || '',
+				name: (btn.innerText || btn.value || btn.getAttribute('aria-label') || '').trim().substring(0, 50),
+				selector: cssPath(btn),
+				type: btn.type || '',
+				visible: r.width > 0 && r.height > 0,
+				enabled: !btn.disabled
+			};
+		}
+
+		forms.push({
+			id: form.id || '',
+			name: form.getAttribute('name') || form.getAttribute('aria-label') || '',
+			selector: cssPath(form),
+			inputs: inputs,
+			submit: submit,
+			isComplete: inputs.every(i => !i.required || i.value !== '')
+		});
+	}));
+	
+	return {
+		elements: results,
+		forms: forms,
+		scrollY: Math.round(window.scrollY),
+		viewport: {width: window.innerWidth, height: window.innerHeight},
+		scripts: Array.from(document.scripts).map(sc => sc.src).filter(Boolean),
+		hasModal: hasModal,
+		totalElements: count,
+		next: next,
+		fresh: fresh,
+		listItems: listItems,
+		pageContent: pageContent
+	};
+}`
+
 func (e *Extractor) Extract(ctx context.Context) (*types.PageState, error) {
 	select {
 	case <-ctx.Done():
@@ -40,172 +476,191 @@ func (e *Extractor) Extract(ctx context.Context) (*types.PageState, error) {
 		return nil, fmt.Errorf("failed to get page info: %w", err)
 	}
 
-	// JavaScript для извлечения элементов И контента
-	// ОБНОВЛЕННАЯ ВЕРСИЯ
-	jsCode := `() => {
-		window._ai_elements = [];
-		const results = [];
-		
-		// === 1. ИНТЕРАКТИВНЫЕ ЭЛЕМЕНТЫ ===
-		const selectors = [
-			'a[href]',
-			'button',
-			'input', // Убрали not(hidden), проверим видимость в коде
-			'textarea',
-			'select',
-			'[role="button"]',
-			'[role="checkbox"]',
-			'[role="link"]',
-			'[role="menuitem"]',
-			'[role="tab"]',
-			'[onclick]',
-			'[title]', 
-			'[data-title-shortcut]',
-			// Специфичные классы для почтовиков (чекбоксы и кнопки)
-			'.mail-MessageSnippet-Checkbox', // Yandex checkbox
-			'.checkbox__box',                // General UI checkbox
-			'.checkbox__control',
-			'[class*="button"]',
-			'[class*="btn"]',
-			'label'
-		];
-		
-		const seen = new Set();
-		
-		selectors.forEach(sel => {
-			try {
-				document.querySelectorAll(sel).forEach(el => {
-					if (el === document.body || el === document.documentElement) return;
-					if (seen.has(el)) return;
-					
-					const rect = el.getBoundingClientRect();
-					const style = window.getComputedStyle(el);
-					
-					// ХИТРАЯ ПРОВЕРКА ВИДИМОСТИ
-					// Некоторые чекбоксы (input) имеют opacity 0, но лежат поверх видимого элемента
-					let isVisible = 
-						rect.width > 0 && 
-						rect.height > 0 && 
-						style.display !== 'none' && 
-						style.visibility !== 'hidden';
-
-					// Если это не input, требуем непрозрачность
-					if (el.tagName.toLowerCase() !== 'input' && !el.classList.contains('checkbox__control')) {
-						if (parseFloat(style.opacity) < 0.1) isVisible = false;
-					}
-					
-					if (!isVisible) return;
-
-					seen.add(el);
-					const id = window._ai_elements.length;
-					window._ai_elements.push(el);
-					
-					// === ИЗВЛЕЧЕНИЕ ТЕКСТА ===
-					let text = '';
-					
-					// 1. Значения полей
-					if (el.tagName.toLowerCase() === 'input' || el.tagName.toLowerCase() === 'textarea') {
-						text = el.value || el.placeholder || '';
-					} 
-					// 2. Текст внутри
-					else {
-						text = el.innerText || el.textContent || '';
-					}
+	pageState := &types.PageState{
+		Title:     info.Title,
+		URL:       info.URL,
+		Timestamp: time.Now(),
+	}
 
-					// 3. Атрибуты (title, aria)
-					if (!text.trim()) {
-						text = el.getAttribute('title') || 
-							   el.getAttribute('aria-label') || 
-							   el.getAttribute('data-title-shortcut') || '';
-					}
+	matched := e.profiles.Match(info.URL)
+	for _, p := range matched {
+		pageState.SiteProfiles = append(pageState.SiteProfiles, p.Name)
+	}
+	rules := newSiteRules(matched)
 
-					// 4. ЕСЛИ ЭТО ЧЕКБОКС БЕЗ ТЕКСТА (ВАЖНО!)
-					// Пытаемся найти тему письма рядом, чтобы ЛЛМ поняла "Чекбокс для письма X"
-					const isCheckbox = el.getAttribute('role') === 'checkbox' || 
-									   el.classList.contains('mail-MessageSnippet-Checkbox') ||
-									   el.type === 'checkbox';
-					
-					if (isCheckbox && !text) {
-						// Ищем родительскую строку таблицы/списка
-						const row = el.closest('[role="row"], .mail-MessageSnippet, .letter-list-item-content');
-						if (row) {
-							// Ищем тему или отправителя в этой строке
-							const subject = row.querySelector('.mail-MessageSnippet-Item_subject, .ll-sj, .bog');
-							if (subject) text = "Выбрать: " + subject.innerText;
-							else text = "Чекбокс выбора";
-						} else {
-							text = "Чекбокс";
-						}
-					}
-					
-					text = text.trim().replace(/\s+/g, ' ').substring(0, 150);
-					
-					// Фильтрация мусора (пустые span/div без роли)
-					const tag = el.tagName.toLowerCase();
-					if (!text && !['input', 'select', 'textarea', 'button'].includes(tag) && !isCheckbox) {
-						// Если нет текста и это не кнопка/инпут - пропускаем, если нет вложенного SVG с title
-						const svgTitle = el.querySelector('svg title');
-						if (svgTitle) text = svgTitle.textContent.trim();
-						else return; 
-					}
-					
-					// Определяем тип для ЛЛМ
-					let role = el.getAttribute('role') || '';
-					if (isCheckbox) role = 'checkbox';
-					
-					results.push({
-						id: id,
-						tag: tag,
-						text: text,
-						type: el.type || '',
-						title: el.getAttribute('title') || '',
-						role: role,
-						// Маркер, что это похоже на чекбокс
-						isCheckbox: isCheckbox
-					});
-				});
-			} catch (e) {}
-		});
-		
-		// === ТЕКСТОВЫЙ КОНТЕНТ (Остался прежним) ===
-		let pageContent = [];
-		let mailItems = [];
-		
-		// Сбор контента (упрощено для примера, используй логику из предыдущего ответа для контента)
-		const mailPatterns = ['.mail-MessageSnippet', '.letter-list-item', '.zA', '[role="row"]'];
-		for (const pattern of mailPatterns) {
-			const items = document.querySelectorAll(pattern);
-			if (items.length > 0) {
-				items.forEach((item, idx) => {
-					if (idx < 15) {
-						const t = item.innerText.replace(/\s+/g, ' ').substring(0, 200);
-						if(t.length > 10) mailItems.push({index: idx+1, content: t});
-					}
-				});
-				if(mailItems.length) break;
+	// Обходим основную страницу и все вложенные фреймы, ID продолжают нумерацию
+	var contentParts []string
+	next := e.nextIDs[e.page.TargetID]
+	for i, frame := range browser.Frames(e.page) {
+		fr, err := e.extractFrame(frame, i == 0, next, rules)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			if e.logger != nil {
+				e.logger.Debug("Frame extraction skipped", "error", err)
 			}
+			continue
 		}
-		
-		// Fallback content
-		if (!mailItems.length) {
-			document.querySelectorAll('h1, h2, .letter-body, .article').forEach(el => {
-				const t = el.innerText.replace(/\s+/g, ' ');
-				if(t.length > 20) pageContent.push(t.substring(0,500));
-			});
+
+		// Метрики прокрутки и скрипты берём у основного фрейма
+		if i == 0 {
+			pageState.ScrollY = fr.scrollY
+			pageState.Viewport.Width = fr.viewportWidth
+			pageState.Viewport.Height = fr.viewportHeight
+			pageState.Scripts = fr.scripts
+			pageState.NewDocument = fr.fresh
 		}
 
-		let hasModal = !!document.querySelector('[role="dialog"], .modal');
-		
-		return {
-			elements: results,
-			hasModal: hasModal,
-			totalElements: window._ai_elements.length,
-			mailItems: mailItems,
-			pageContent: pageContent
-		};
-	}`
+		pageState.Elements = append(pageState.Elements, fr.elements...)
+		pageState.Forms = append(pageState.Forms, fr.forms...)
+		pageState.ElementCount += fr.total
+		next = fr.next
+		pageState.HasModal = pageState.HasModal || fr.hasModal
+		if fr.content != "" {
+			contentParts = append(contentParts, fr.content)
+		}
+	}
 
-	res, err := e.page.Eval(jsCode)
+	pageState.Content = strings.Join(contentParts, "\n")
+
+	for _, el := range pageState.Elements {
+		switch el.Tag {
+		case "input", "textarea", "select":
+			pageState.InputCount++
+		case "button":
+			pageState.ButtonCount++
+		}
+
+		if href, ok := el.Attributes["href"]; ok {
+			pageState.Links = append(pageState.Links, types.LinkElement{
+				ID:        el.Attributes["id"],
+				Href:      href,
+				Text:      el.Text,
+				Selector:  el.Selector,
+				Visible:   el.Visible,
+				Clickable: el.Clickable,
+				Rel:       el.Attributes["rel"],
+				Title:     el.Attributes["title"],
+				ElementID: el.ID,
+			})
+		}
+	}
+	pageState.LinkCount = len(pageState.Links)
+
+	// Счётчик ID общий для всех фреймов вкладки
+	e.nextIDs[e.page.TargetID] = next
+	if _, err := e.page.Eval(`(next) => { window._ai_next = next; }`, next); err != nil {
+		return nil, fmt.Errorf("failed to store element counter: %w", err)
+	}
+
+	if e.logger != nil {
+		e.logger.Debug("Extracted elements", "count", len(pageState.Elements), "hasModal", pageState.HasModal)
+	}
+
+	e.last = pageState
+
+	return pageState, nil
+}
+
+// siteRules — правила профилей сайта, объединённые для extractScript
+type siteRules struct {
+	Selectors  []string `json:"selectors"`
+	Checkboxes []string `json:"checkboxes"`
+	Rows       []string `json:"rows"`
+	RowLabels  []string `json:"rowLabels"`
+	Blocks     []string `json:"blocks"`
+
+	// buttons проверяются на стороне Go, в скрипт не передаются
+	buttons []string
+}
+
+func newSiteRules(matched []*profiles.Profile) *siteRules {
+	// Пустые срезы, а не nil: скрипт разворачивает их через spread
+	r := &siteRules{
+		Selectors:  []string{},
+		Checkboxes: []string{},
+		Rows:       []string{},
+		RowLabels:  []string{},
+		Blocks:     []string{},
+	}
+	for _, p := range matched {
+		r.Selectors = append(r.Selectors, p.Elements.Selectors...)
+		r.Checkboxes = append(r.Checkboxes, p.Elements.Checkboxes...)
+		r.Rows = append(r.Rows, p.Content.Rows...)
+		r.RowLabels = append(r.RowLabels, p.Content.RowLabels...)
+		r.Blocks = append(r.Blocks, p.Content.Blocks...)
+		for _, b := range p.Elements.Buttons {
+			r.buttons = append(r.buttons, strings.ToLower(b))
+		}
+	}
+	return r
+}
+
+// isButtonText сообщает, что текст элемента совпадает с одним из слов-кнопок профиля
+func (r *siteRules) isButtonText(text string) bool {
+	text = strings.ToLower(text)
+	for _, b := range r.buttons {
+		if strings.Contains(text, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// frameResult — результат извлечения одного фрейма
+type frameResult struct {
+	elements       []types.PageElement
+	forms          []types.FormElement
+	total          int
+	next           int
+	fresh          bool
+	hasModal       bool
+	content        string
+	scrollY        int
+	viewportWidth  int
+	viewportHeight int
+	scripts        []string
+}
+
+// jsRect — прямоугольник getBoundingClientRect в координатах фрейма
+type jsRect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+type jsFormInput struct {
+	ElementID   int    `json:"elementId"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Selector    string `json:"selector"`
+	Required    bool   `json:"required"`
+	Placeholder string `json:"placeholder"`
+	Value       string `json:"value"`
+}
+
+type jsForm struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Selector string        `json:"selector"`
+	Inputs   []jsFormInput `json:"inputs"`
+	Submit   *struct {
+		ElementID int    `json:"elementId"`
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		Selector  string `json:"selector"`
+		Type      string `json:"type"`
+		Visible   bool   `json:"visible"`
+		Enabled   bool   `json:"enabled"`
+	} `json:"submit"`
+	IsComplete bool `json:"isComplete"`
+}
+
+func (e *Extractor) extractFrame(frame browser.Frame, top bool, next int, rules *siteRules) (*frameResult, error) {
+	res, err := frame.Page.Eval(extractScript, map[string]interface{}{"top": top, "next": next, "site": rules})
 	if err != nil {
 		return nil, fmt.Errorf("JS extraction failed: %w", err)
 	}
@@ -213,22 +668,52 @@ func (e *Extractor) Extract(ctx context.Context) (*types.PageState, error) {
 	// Структура результата JS
 	var jsResult struct {
 		Elements []struct {
-			ID         int    `json:"id"`
-			Tag        string `json:"tag"`
-			Text       string `json:"text"`
-			Type       string `json:"type"`
-			Href       string `json:"href"`
-			Title      string `json:"title"` // Добавили Title
-			Role       string `json:"role"`
-			IsButton   bool   `json:"isButton"`
-			IsCheckbox bool   `json:"isCheckbox"`
+			ID          int    `json:"id"`
+			Tag         string `json:"tag"`
+			Text        string `json:"text"`
+			Type        string `json:"type"`
+			Href        string `json:"href"`
+			Title       string `json:"title"` // Добавили Title
+			Role        string `json:"role"`
+			DomID       string `json:"domId"`
+			Name        string `json:"name"`
+			Placeholder string `json:"placeholder"`
+			AriaLabel   string `json:"ariaLabel"`
+			Rel         string `json:"rel"`
+			Required    bool   `json:"required"`
+			Selector    string `json:"selector"`
+			Clickable   bool   `json:"clickable"`
+			Rect        jsRect `json:"rect"`
+			IsButton    bool   `json:"isButton"`
+			IsCheckbox  bool   `json:"isCheckbox"`
+			Value       string `json:"value"`
+			Checked     *bool  `json:"checked"`
+			Options     []struct {
+				Value    string `json:"value"`
+				Label    string `json:"label"`
+				Selected bool   `json:"selected"`
+				Disabled bool   `json:"disabled"`
+			} `json:"options"`
+			OptionCount int    `json:"optionCount"`
+			Min         string `json:"min"`
+			Max         string `json:"max"`
+			Step        string `json:"step"`
 		} `json:"elements"`
-		HasModal      bool `json:"hasModal"`
-		TotalElements int  `json:"totalElements"`
-		MailItems     []struct {
+		Forms    []jsForm `json:"forms"`
+		ScrollY  int      `json:"scrollY"`
+		Viewport struct {
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		} `json:"viewport"`
+		Scripts       []string `json:"scripts"`
+		HasModal      bool     `json:"hasModal"`
+		TotalElements int      `json:"totalElements"`
+		Next          int      `json:"next"`
+		Fresh         bool     `json:"fresh"`
+		ListItems     []struct {
 			Index   int    `json:"index"`
 			Content string `json:"content"`
-		} `json:"mailItems"`
+		} `json:"listItems"`
 		PageContent []string `json:"pageContent"`
 	}
 
@@ -237,12 +722,15 @@ func (e *Extractor) Extract(ctx context.Context) (*types.PageState, error) {
 		return nil, fmt.Errorf("failed to parse JS result: %w", err)
 	}
 
-	pageState := &types.PageState{
-		Title:        info.Title,
-		URL:          info.URL,
-		HasModal:     jsResult.HasModal,
-		ElementCount: jsResult.TotalElements,
-		Timestamp:    time.Now(),
+	result := &frameResult{
+		total:          jsResult.TotalElements,
+		next:           jsResult.Next,
+		fresh:          jsResult.Fresh,
+		hasModal:       jsResult.HasModal,
+		scrollY:        jsResult.ScrollY,
+		viewportWidth:  jsResult.Viewport.Width,
+		viewportHeight: jsResult.Viewport.Height,
+		scripts:        jsResult.Scripts,
 	}
 
 	// Конвертируем элементы
@@ -260,21 +748,101 @@ func (e *Extractor) Extract(ctx context.Context) (*types.PageState, error) {
 		if elem.Role != "" {
 			attrs["role"] = elem.Role
 		}
+		if elem.DomID != "" {
+			attrs["id"] = elem.DomID
+		}
+		if elem.Name != "" {
+			attrs["name"] = elem.Name
+		}
+		if elem.Placeholder != "" {
+			attrs["placeholder"] = elem.Placeholder
+		}
+		if elem.AriaLabel != "" {
+			attrs["aria-label"] = elem.AriaLabel
+		}
+		if elem.Rel != "" {
+			attrs["rel"] = elem.Rel
+		}
+		if elem.Required {
+			attrs["required"] = "true"
+		}
+		if elem.Min != "" {
+			attrs["min"] = elem.Min
+		}
+		if elem.Max != "" {
+			attrs["max"] = elem.Max
+		}
+		if elem.Step != "" {
+			attrs["step"] = elem.Step
+		}
 
 		// Улучшаем отображение тега для ЛЛМ
 		tag := elem.Tag
-		if elem.IsButton || elem.Role == "button" || strings.Contains(strings.ToLower(elem.Text), "удалить") {
+		if elem.IsButton || elem.Role == "button" || rules.isButtonText(elem.Text) {
 			tag = "button" // Подменяем tag для ЛЛМ, чтобы он понимал, что это кнопка
 		}
 
 		pe := types.PageElement{
-			ID:         elem.ID,
-			Tag:        tag,
-			Text:       elem.Text,
-			Attributes: attrs,
-			Visible:    true,
+			ID:            elem.ID,
+			Selector:      elem.Selector,
+			Tag:           tag,
+			Text:          elem.Text,
+			Attributes:    attrs,
+			Clickable:     elem.Clickable,
+			Visible:       true,
+			DiscoveryTime: time.Now(),
+			Value:         elem.Value,
+			Checked:       elem.Checked,
+			OptionCount:   elem.OptionCount,
+		}
+		for _, o := range elem.Options {
+			pe.Options = append(pe.Options, types.SelectOption{
+				Value:    o.Value,
+				Label:    o.Label,
+				Selected: o.Selected,
+				Disabled: o.Disabled,
+			})
 		}
-		pageState.Elements = append(pageState.Elements, pe)
+		// Координаты приводим к viewport вкладки
+		pe.Position.X = int(elem.Rect.X + frame.OffsetX)
+		pe.Position.Y = int(elem.Rect.Y + frame.OffsetY)
+		pe.Position.Width = int(elem.Rect.Width)
+		pe.Position.Height = int(elem.Rect.Height)
+
+		result.elements = append(result.elements, pe)
+	}
+
+	for _, f := range jsResult.Forms {
+		form := types.FormElement{
+			ID:         f.ID,
+			Name:       f.Name,
+			Selector:   f.Selector,
+			IsComplete: f.IsComplete,
+		}
+		for _, in := range f.Inputs {
+			form.Inputs = append(form.Inputs, types.InputField{
+				ID:          in.ID,
+				Name:        in.Name,
+				Type:        in.Type,
+				Selector:    in.Selector,
+				Required:    in.Required,
+				Placeholder: in.Placeholder,
+				Value:       in.Value,
+				ElementID:   in.ElementID,
+			})
+		}
+		if f.Submit != nil {
+			form.SubmitBtn = &types.SubmitButton{
+				ID:        f.Submit.ID,
+				Name:      f.Submit.Name,
+				Selector:  f.Submit.Selector,
+				Type:      f.Submit.Type,
+				Visible:   f.Submit.Visible,
+				Enabled:   f.Submit.Enabled,
+				ElementID: f.Submit.ElementID,
+			}
+		}
+		result.forms = append(result.forms, form)
 	}
 
 	// Сохраняем контент
@@ -285,27 +853,105 @@ func (e *Extractor) Extract(ctx context.Context) (*types.PageState, error) {
 		contentParts = append(contentParts, jsResult.PageContent...)
 	}
 	// Потом список писем
-	if len(jsResult.MailItems) > 0 {
+	if len(jsResult.ListItems) > 0 {
 		contentParts = append(contentParts, "--- LIST ITEMS ---")
-		for _, item := range jsResult.MailItems {
+		for _, item := range jsResult.ListItems {
 			contentParts = append(contentParts, fmt.Sprintf("%d. %s", item.Index, item.Content))
 		}
 	}
 
-	pageState.Content = strings.Join(contentParts, "\n")
+	result.content = strings.Join(contentParts, "\n")
 
-	if e.logger != nil {
-		e.logger.Debug("Extracted elements", "count", len(pageState.Elements), "hasModal", pageState.HasModal)
+	return result, nil
+}
+
+// writeHeader пишет общий заголовок состояния: страница, URL, вкладка и история навигации
+func writeHeader(b *strings.Builder, state *types.PageState) {
+	b.WriteString(fmt.Sprintf("## Page: %s\n", state.Title))
+	b.WriteString(fmt.Sprintf("## URL: %s\n", state.URL))
+
+	if state.TabCount > 0 {
+		b.WriteString(fmt.Sprintf("## Tab: [%d] of %d", state.Tab.ID, state.TabCount))
+		if state.Tab.Popup {
+			if state.Tab.OpenerID > 0 {
+				b.WriteString(fmt.Sprintf(" (popup opened by tab [%d])", state.Tab.OpenerID))
+			} else {
+				b.WriteString(" (popup)")
+			}
+		}
+		if state.TabCount > 1 {
+			b.WriteString(" - list_tabs to see all")
+		}
+		b.WriteString("\n")
 	}
 
-	return pageState, nil
+	if h := state.History; len(h.Back) > 0 || len(h.Forward) > 0 {
+		b.WriteString(fmt.Sprintf("## History: %d back, %d forward", len(h.Back), len(h.Forward)))
+		if len(h.Back) > 0 {
+			b.WriteString(" | back: " + formatHistoryEntry(h.Back[len(h.Back)-1]))
+		}
+		if len(h.Forward) > 0 {
+			b.WriteString(" | forward: " + formatHistoryEntry(h.Forward[0]))
+		}
+		b.WriteString("\n")
+	}
+
+	if d := state.Dialog; d != nil {
+		b.WriteString(fmt.Sprintf("## Dialog: %s %q", d.Type, d.Message))
+		if d.Type == "prompt" && d.DefaultPrompt != "" {
+			b.WriteString(fmt.Sprintf(" (default answer %q)", d.DefaultPrompt))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// dialogActions — как ответить на диалог каждого вида
+var dialogActions = map[string]string{
+	"alert":        "Read the message and close it with handle_dialog(accept=true).",
+	"confirm":      "handle_dialog(accept=true) presses OK, accept=false presses Cancel.",
+	"prompt":       "handle_dialog(accept=true, text=\"...\") submits the answer, accept=false cancels.",
+	"beforeunload": "handle_dialog(accept=true) leaves the page and loses unsaved changes, accept=false stays on the page.",
+}
+
+// FormatDialogForLLM описывает вкладку, заблокированную JS-диалогом. Пока на диалог
+// не ответили, скрипты страницы не выполняются и элементы недоступны.
+func (e *Extractor) FormatDialogForLLM(state *types.PageState) string {
+	var b strings.Builder
+
+	writeHeader(&b, state)
+	b.WriteString("\n⚠️ **JS DIALOG OPEN** - The page is blocked until you answer the dialog. ")
+	b.WriteString(dialogActions[state.Dialog.Type])
+	if state.Dialog.Destructive {
+		b.WriteString(" It confirms an irreversible action: accepting it asks the user for confirmation.")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatHistoryEntry — запись истории для заголовка: заголовок страницы (до 60 символов) и URL
+func formatHistoryEntry(e types.HistoryEntry) string {
+	title := []rune(e.Title)
+	if len(title) == 0 {
+		return e.URL
+	}
+	if len(title) > 60 {
+		title = append(title[:57], []rune("...")...)
+	}
+	return fmt.Sprintf("%q %s", string(title), e.URL)
 }
 
 func (e *Extractor) FormatForLLM(state *types.PageState) string {
 	var b strings.Builder
 
-	b.WriteString(fmt.Sprintf("## Page: %s\n", state.Title))
-	b.WriteString(fmt.Sprintf("## URL: %s\n\n", state.URL))
+	writeHeader(&b, state)
+	if state.Viewport.Height > 0 {
+		b.WriteString(fmt.Sprintf("## Scroll: %dpx (viewport %dx%d)\n", state.ScrollY, state.Viewport.Width, state.Viewport.Height))
+	}
+	if len(state.SiteProfiles) > 0 {
+		b.WriteString(fmt.Sprintf("## Site profile: %s\n", strings.Join(state.SiteProfiles, ", ")))
+	}
+	b.WriteString("\n")
 
 	if state.HasModal {
 		b.WriteString("⚠️ **MODAL/POPUP DETECTED** - Close it first with Escape or find close button.\n\n")
@@ -320,10 +966,35 @@ func (e *Extractor) FormatForLLM(state *types.PageState) string {
 		b.WriteString("\n```\n\n")
 	}
 
+	// Формы показываем целиком: поля и кнопка отправки одной группой
+	inForm := map[int]bool{}
+	var formBlocks []string
+	for _, form := range state.Forms {
+		block, ids := e.formatForm(form, state.Elements)
+		if block == "" {
+			continue
+		}
+		formBlocks = append(formBlocks, block)
+		for _, id := range ids {
+			inForm[id] = true
+		}
+	}
+	if len(formBlocks) > 0 {
+		b.WriteString("### Forms\n")
+		for _, block := range formBlocks {
+			b.WriteString(block)
+		}
+		b.WriteString("\n")
+	}
+
 	// Группируем элементы
 	var inputs, buttons, links, listItems []types.PageElement
 
 	for _, el := range state.Elements {
+		if inForm[el.ID] {
+			continue
+		}
+
 		switch el.Tag {
 		case "input", "textarea", "select":
 			inputs = append(inputs, el)
@@ -420,5 +1091,134 @@ func (e *Extractor) formatElement(el types.PageElement) string {
 		parts = append(parts, fmt.Sprintf("placeholder=%q", ph))
 	}
 
+	// Состояние элементов формы: тип со своим форматом значения, границы, отметка, варианты
+	if el.Tag == "input" && typedInputs[el.Attributes["type"]] {
+		parts = append(parts, "type="+el.Attributes["type"])
+		for _, attr := range []string{"min", "max", "step"} {
+			if v := el.Attributes[attr]; v != "" {
+				parts = append(parts, fmt.Sprintf("%s=%s", attr, v))
+			}
+		}
+	}
+	if el.Checked != nil {
+		if *el.Checked {
+			parts = append(parts, "checked")
+		} else {
+			parts = append(parts, "unchecked")
+		}
+	}
+	if len(el.Options) > 0 {
+		parts = append(parts, "options: "+formatOptions(el.Options, el.OptionCount))
+	}
+
 	return strings.Join(parts, " ")
 }
+
+// typedInputs — типы input, для которых модели нужны тип и границы значения
+var typedInputs = map[string]bool{
+	"checkbox":       true,
+	"radio":          true,
+	"date":           true,
+	"time":           true,
+	"datetime-local": true,
+	"month":          true,
+	"week":           true,
+	"range":          true,
+	"number":         true,
+	"color":          true,
+}
+
+// maxShownOptions — сколько вариантов select показывать модели
+const maxShownOptions = 15
+
+// formatOptions перечисляет варианты select; выбранные помечены *
+func formatOptions(options []types.SelectOption, total int) string {
+	var items []string
+	for i, o := range options {
+		if i == maxShownOptions {
+			break
+		}
+		item := fmt.Sprintf("%q", o.Label)
+		if o.Label == "" {
+			item = fmt.Sprintf("value=%q", o.Value)
+		}
+		if o.Selected {
+			item += "*"
+		}
+		if o.Disabled {
+			item += " (disabled)"
+		}
+		items = append(items, item)
+	}
+
+	if total < len(options) {
+		total = len(options)
+	}
+	if rest := total - len(items); rest > 0 {
+		items = append(items, fmt.Sprintf("... +%d more", rest))
+	}
+	return strings.Join(items, ", ")
+}
+
+// formatForm выводит форму одним блоком и возвращает ID вошедших в неё элементов.
+// Поля, которых нет в списке элементов (невидимые), не показываются.
+func (e *Extractor) formatForm(form types.FormElement, elements []types.PageElement) (string, []int) {
+	byID := make(map[int]types.PageElement, len(elements))
+	for _, el := range elements {
+		byID[el.ID] = el
+	}
+
+	var lines []string
+	var ids []int
+	for _, in := range form.Inputs {
+		el, ok := byID[in.ElementID]
+		if !ok {
+			continue
+		}
+		line := "  " + e.formatElement(el)
+		if in.Type != "" && in.Type != el.Tag && !typedInputs[in.Type] {
+			line += fmt.Sprintf(" type=%s", in.Type)
+		}
+		if in.Required {
+			line += " (required)"
+		}
+		lines = append(lines, line)
+		ids = append(ids, el.ID)
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	name := form.Name
+	if name == "" {
+		name = form.ID
+	}
+	status := "incomplete"
+	if form.IsComplete {
+		status = "all required fields filled"
+	}
+
+	var b strings.Builder
+	if name != "" {
+		b.WriteString(fmt.Sprintf("Form %q (%s):\n", name, status))
+	} else {
+		b.WriteString(fmt.Sprintf("Form (%s):\n", status))
+	}
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+
+	if form.SubmitBtn != nil {
+		if el, ok := byID[form.SubmitBtn.ElementID]; ok {
+			submit := "  submit: " + e.formatElement(el)
+			if !form.SubmitBtn.Enabled {
+				submit += " (disabled)"
+			}
+			b.WriteString(submit + "\n")
+			ids = append(ids, el.ID)
+		}
+	}
+
+	return b.String(), ids
+}
@@ -0,0 +1,212 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stannisl/ai-browser-assistant/internal/profiles"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func TestFormatForLLM_Forms(t *testing.T) {
+	e := New(nil, nil)
+	state := &types.PageState{
+		Title: "Login",
+		URL:   "https://example.com/login",
+		Elements: []types.PageElement{
+			{ID: 0, Tag: "input", Attributes: map[string]string{"placeholder": "Email"}},
+			{ID: 1, Tag: "input"},
+			{ID: 2, Tag: "button", Text: "Sign in"},
+			{ID: 3, Tag: "button", Text: "Help"},
+		},
+		Forms: []types.FormElement{{
+			Name: "login",
+			Inputs: []types.InputField{
+				{ElementID: 0, Type: "email", Required: true},
+				{ElementID: 1, Type: "password", Required: true},
+				{ElementID: -1, Type: "text"},
+			},
+			SubmitBtn: &types.SubmitButton{ElementID: 2, Enabled: true},
+		}},
+		ElementCount: 4,
+	}
+
+	out := e.FormatForLLM(state)
+
+	for _, want := range []string{
+		`Form "login" (incomplete):`,
+		`  [0] input placeholder="Email" type=email (required)`,
+		`  [1] input type=password (required)`,
+		`  submit: [2] button "Sign in"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// Элементы формы не дублируются в общих секциях
+	if strings.Count(out, "[2] button") != 1 {
+		t.Errorf("expected submit button to be listed once, got:\n%s", out)
+	}
+	if !strings.Contains(out, `[3] button "Help"`) {
+		t.Errorf("expected button outside the form to be listed, got:\n%s", out)
+	}
+}
+
+func TestSiteRules(t *testing.T) {
+	rules := newSiteRules([]*profiles.Profile{
+		{
+			Name:     "mail",
+			Elements: profiles.ElementRules{Selectors: []string{".check"}, Buttons: []string{"Удалить"}},
+			Content:  profiles.ContentRules{Rows: []string{".row"}},
+		},
+		{
+			Name:     "extra",
+			Elements: profiles.ElementRules{Buttons: []string{"archive"}},
+		},
+	})
+
+	if len(rules.Selectors) != 1 || len(rules.Rows) != 1 || rules.Checkboxes == nil || rules.Blocks == nil {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	for text, want := range map[string]bool{
+		"удалить письмо": true,
+		"Archive":        true,
+		"Ответить":       false,
+	} {
+		if got := rules.isButtonText(text); got != want {
+			t.Errorf("isButtonText(%q) = %v, want %v", text, got, want)
+		}
+	}
+
+	if newSiteRules(nil).isButtonText("удалить") {
+		t.Error("without profiles no text should be treated as a button")
+	}
+}
+
+func TestFormatForLLM_TabHeader(t *testing.T) {
+	e := New(nil, nil)
+	state := &types.PageState{
+		Title:    "Sign in",
+		URL:      "https://accounts.example.com/oauth",
+		Tab:      types.TabInfo{ID: 3, Popup: true, OpenerID: 1},
+		TabCount: 3,
+	}
+
+	out := e.FormatForLLM(state)
+	if !strings.Contains(out, "## Tab: [3] of 3 (popup opened by tab [1]) - list_tabs to see all") {
+		t.Errorf("expected tab header, got:\n%s", out)
+	}
+
+	state.TabCount = 0
+	if strings.Contains(e.FormatForLLM(state), "## Tab:") {
+		t.Error("tab header should be omitted when tabs are unknown")
+	}
+}
+
+func TestFormatForLLM_HistoryHeader(t *testing.T) {
+	e := New(nil, nil)
+	state := &types.PageState{
+		Title: "Result",
+		URL:   "https://example.com/item/1",
+		History: types.HistoryInfo{
+			Back: []types.HistoryEntry{
+				{Title: "Home", URL: "https://example.com/"},
+				{Title: "Search results", URL: "https://example.com/search?q=go"},
+			},
+			Forward: []types.HistoryEntry{{URL: "https://example.com/item/2"}},
+		},
+	}
+
+	out := e.FormatForLLM(state)
+	want := `## History: 2 back, 1 forward | back: "Search results" https://example.com/search?q=go | forward: https://example.com/item/2`
+	if !strings.Contains(out, want) {
+		t.Errorf("expected history header, got:\n%s", out)
+	}
+
+	state.History = types.HistoryInfo{}
+	if strings.Contains(e.FormatForLLM(state), "## History:") {
+		t.Error("history header should be omitted for a tab without history")
+	}
+}
+
+func TestFormatDialogForLLM(t *testing.T) {
+	e := New(nil, nil)
+	state := &types.PageState{
+		Title:    "Inbox",
+		URL:      "https://mail.example.com/",
+		TabCount: 1,
+		Dialog:   &types.DialogInfo{Type: "confirm", Message: "Delete permanently?", Destructive: true},
+	}
+
+	out := e.FormatDialogForLLM(state)
+	for _, want := range []string{
+		`## Dialog: confirm "Delete permanently?"`,
+		"JS DIALOG OPEN",
+		"accept=false presses Cancel",
+		"asks the user for confirmation",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	state.Dialog = &types.DialogInfo{Type: "prompt", Message: "Your name?", DefaultPrompt: "guest"}
+	out = e.FormatDialogForLLM(state)
+	if !strings.Contains(out, `## Dialog: prompt "Your name?" (default answer "guest")`) || strings.Contains(out, "user for confirmation") {
+		t.Errorf("unexpected prompt dialog output:\n%s", out)
+	}
+}
+
+func TestFormatElement_FormControls(t *testing.T) {
+	e := New(nil, nil)
+	checked := true
+
+	options := make([]types.SelectOption, 0, 20)
+	options = append(options, types.SelectOption{Value: "ru", Label: "Russia", Selected: true})
+	options = append(options, types.SelectOption{Value: "kz", Label: "Kazakhstan", Disabled: true})
+	for i := 0; i < 18; i++ {
+		options = append(options, types.SelectOption{Value: fmt.Sprintf("c%d", i), Label: fmt.Sprintf("Country %d", i)})
+	}
+
+	tests := []struct {
+		name string
+		el   types.PageElement
+		want string
+	}{
+		{
+			name: "checkbox",
+			el:   types.PageElement{ID: 1, Tag: "input", Text: "Remember me", Attributes: map[string]string{"type": "checkbox"}, Checked: &checked},
+			want: `[1] input "Remember me" type=checkbox checked`,
+		},
+		{
+			name: "range",
+			el:   types.PageElement{ID: 2, Tag: "input", Text: "50", Attributes: map[string]string{"type": "range", "min": "0", "max": "100", "step": "10"}},
+			want: `[2] input "50" type=range min=0 max=100 step=10`,
+		},
+		{
+			name: "date",
+			el:   types.PageElement{ID: 3, Tag: "input", Attributes: map[string]string{"type": "date", "min": "2024-01-01"}},
+			want: `[3] input type=date min=2024-01-01`,
+		},
+		{
+			name: "select",
+			el:   types.PageElement{ID: 4, Tag: "select", Text: "Russia", Options: options, OptionCount: 120},
+			want: `[4] select "Russia" options: "Russia"*, "Kazakhstan" (disabled), "Country 0"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.formatElement(tt.el); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("formatElement() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := e.formatElement(tests[3].el); !strings.HasSuffix(got, `"Country 12", ... +105 more`) {
+		t.Errorf("expected options to be cut at %d with a remainder, got %q", maxShownOptions, got)
+	}
+}
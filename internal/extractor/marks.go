@@ -0,0 +1,130 @@
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// markPalette — цвета рамок; соседние элементы получают разные цвета, чтобы номера не сливались
+var markPalette = []color.RGBA{
+	{R: 230, G: 25, B: 75, A: 255},
+	{R: 60, G: 130, B: 40, A: 255},
+	{R: 0, G: 90, B: 200, A: 255},
+	{R: 200, G: 100, B: 0, A: 255},
+	{R: 145, G: 30, B: 180, A: 255},
+	{R: 0, G: 130, B: 130, A: 255},
+}
+
+// digitGlyphs — цифры 3x5 пикселей, по строке на элемент, старший бит слева
+var digitGlyphs = [10][5]uint8{
+	{7, 5, 5, 5, 7}, // 0
+	{2, 6, 2, 2, 7}, // 1
+	{7, 1, 7, 4, 7}, // 2
+	{7, 1, 7, 1, 7}, // 3
+	{5, 5, 7, 1, 1}, // 4
+	{7, 4, 7, 1, 7}, // 5
+	{7, 4, 7, 5, 7}, // 6
+	{7, 1, 1, 1, 1}, // 7
+	{7, 5, 7, 5, 7}, // 8
+	{7, 5, 7, 1, 7}, // 9
+}
+
+const (
+	// glyphScale — во сколько раз увеличивается шрифт 3x5
+	glyphScale = 2
+	// markBorder — толщина рамки в пикселях изображения
+	markBorder = 2
+)
+
+// DrawMarks рисует на скриншоте рамки видимых элементов с их ID из extract_page (set-of-marks).
+// Координаты элементов берутся в CSS-пикселях viewport и масштабируются под размер
+// изображения по ширине viewport из state.
+func DrawMarks(screenshot []byte, state *types.PageState) ([]byte, error) {
+	decoded, err := png.Decode(bytes.NewReader(screenshot))
+	if err != nil {
+		return nil, fmt.Errorf("decode screenshot: %w", err)
+	}
+
+	img := image.NewRGBA(decoded.Bounds())
+	draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+
+	scale := 1.0
+	if state.Viewport.Width > 0 {
+		scale = float64(img.Bounds().Dx()) / float64(state.Viewport.Width)
+	}
+
+	for i, el := range state.Elements {
+		box := image.Rect(
+			int(float64(el.Position.X)*scale),
+			int(float64(el.Position.Y)*scale),
+			int(float64(el.Position.X+el.Position.Width)*scale),
+			int(float64(el.Position.Y+el.Position.Height)*scale),
+		)
+		if box.Empty() || !box.Overlaps(img.Bounds()) {
+			continue
+		}
+
+		c := markPalette[i%len(markPalette)]
+		drawFrame(img, box, c)
+		drawLabel(img, box.Min, strconv.Itoa(el.ID), c)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode screenshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawFrame рисует рамку по границе прямоугольника внутрь
+func drawFrame(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	src := image.NewUniform(c)
+	sides := []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+markBorder),
+		image.Rect(r.Min.X, r.Max.Y-markBorder, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y, r.Min.X+markBorder, r.Max.Y),
+		image.Rect(r.Max.X-markBorder, r.Min.Y, r.Max.X, r.Max.Y),
+	}
+	for _, side := range sides {
+		draw.Draw(img, side.Intersect(img.Bounds()), src, image.Point{}, draw.Src)
+	}
+}
+
+// drawLabel рисует номер белыми цифрами на плашке цвета рамки в левом верхнем углу элемента.
+// Плашка прижимается к краям изображения, чтобы номер не обрезался.
+func drawLabel(img *image.RGBA, at image.Point, text string, c color.RGBA) {
+	const pad = 2
+	digitW, digitH := 3*glyphScale, 5*glyphScale
+	w := len(text)*(digitW+glyphScale) - glyphScale + 2*pad
+	h := digitH + 2*pad
+
+	bounds := img.Bounds()
+	at.X = max(bounds.Min.X, min(at.X, bounds.Max.X-w))
+	at.Y = max(bounds.Min.Y, min(at.Y, bounds.Max.Y-h))
+
+	label := image.Rect(at.X, at.Y, at.X+w, at.Y+h)
+	draw.Draw(img, label.Intersect(bounds), image.NewUniform(c), image.Point{}, draw.Src)
+
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	x := at.X + pad
+	for _, ch := range text {
+		glyph := digitGlyphs[ch-'0']
+		for row := 0; row < 5; row++ {
+			for col := 0; col < 3; col++ {
+				if glyph[row]&(4>>col) == 0 {
+					continue
+				}
+				px := image.Rect(x+col*glyphScale, at.Y+pad+row*glyphScale, x+(col+1)*glyphScale, at.Y+pad+(row+1)*glyphScale)
+				draw.Draw(img, px.Intersect(bounds), image.NewUniform(white), image.Point{}, draw.Src)
+			}
+		}
+		x += digitW + glyphScale
+	}
+}
@@ -0,0 +1,70 @@
+package extractor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func TestDrawMarks(t *testing.T) {
+	// Скриншот с devicePixelRatio 2: 200x100 пикселей на viewport 100x50
+	src := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	state := &types.PageState{}
+	state.Viewport.Width = 100
+	state.Viewport.Height = 50
+
+	visible := types.PageElement{ID: 12}
+	visible.Position.X, visible.Position.Y, visible.Position.Width, visible.Position.Height = 40, 20, 30, 15
+	hidden := types.PageElement{ID: 13}
+	hidden.Position.X, hidden.Position.Y, hidden.Position.Width, hidden.Position.Height = 10, 400, 30, 15
+	state.Elements = []types.PageElement{visible, hidden}
+
+	out, err := DrawMarks(buf.Bytes(), state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	decoded, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := decoded.(*image.RGBA)
+
+	// Правая граница элемента: x = (40+30)*2 - 1
+	if c := img.RGBAAt(139, 60); c != markPalette[0] {
+		t.Errorf("expected frame color at the right border, got %v", c)
+	}
+	// Середина элемента не закрашивается
+	if c := img.RGBAAt(110, 55); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("element interior should stay untouched, got %v", c)
+	}
+	// Плашка с номером в левом верхнем углу элемента
+	if c := img.RGBAAt(81, 41); c != markPalette[0] {
+		t.Errorf("expected label background at the top-left corner, got %v", c)
+	}
+	// Второй элемент вне viewport не рисуется: цвета второй рамки нет нигде
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			if img.RGBAAt(x, y) == markPalette[1] {
+				t.Fatalf("element outside the viewport should not be marked (pixel %d,%d)", x, y)
+			}
+		}
+	}
+}
+
+func TestDrawMarks_InvalidImage(t *testing.T) {
+	if _, err := DrawMarks([]byte("not a png"), &types.PageState{}); err == nil {
+		t.Error("expected error for invalid image")
+	}
+}
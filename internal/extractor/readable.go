@@ -0,0 +1,324 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// Лимит одной страницы read_page. Токены оцениваем грубо: ~4 байта на токен.
+const (
+	readPageMaxTokens = 2000
+	bytesPerToken     = 4
+)
+
+// readableScript находит основной контент страницы (article/main или блок с наибольшим
+// количеством текста) и переводит его в Markdown: заголовки, абзацы, списки, таблицы, ссылки.
+const readableScript = `() => {
+	const SKIP = new Set(['script', 'style', 'noscript', 'template', 'svg', 'canvas', 'nav', 'header', 'footer', 'aside',
+		'form', 'button', 'iframe', 'select', 'input', 'textarea', 'dialog']);
+	const BLOCK = /^(p|div|section|article|main|h[1-6]|ul|ol|li|table|pre|blockquote|dl|dt|dd|figure|figcaption|hr|details|summary)$/;
+
+	const visible = (el) => {
+		const style = window.getComputedStyle(el);
+		return style.display !== 'none' && style.visibility !== 'hidden';
+	};
+	const textLength = (el) => (el.innerText || '').trim().length;
+
+	// === 1. ВЫБОР КОРНЯ ОСНОВНОГО КОНТЕНТА ===
+	let root = null;
+	for (const sel of ['main', '[role="main"]', 'article']) {
+		const candidates = Array.from(document.querySelectorAll(sel)).filter(el => textLength(el) > 200);
+		if (candidates.length === 1) {
+			root = candidates[0];
+			break;
+		}
+	}
+
+	if (!root) {
+		// Упрощённый readability: абзацы начисляют очки родителю и половину — деду
+		const scores = new Map();
+		document.querySelectorAll('p, pre, li, td, blockquote').forEach(el => {
+			const len = textLength(el);
+			if (len < 25) return;
+			const score = 1 + Math.min(3, Math.floor(len / 100)) + (el.innerText.match(/[,.]/g) || []).length / 10;
+			const parent = el.parentElement;
+			if (!parent) return;
+			scores.set(parent, (scores.get(parent) || 0) + score);
+			if (parent.parentElement) {
+				scores.set(parent.parentElement, (scores.get(parent.parentElement) || 0) + score / 2);
+			}
+		});
+
+		let bestScore = 0;
+		scores.forEach((score, el) => {
+			// Штрафуем блоки, состоящие из ссылок (меню, списки тегов)
+			const links = Array.from(el.querySelectorAll('a')).reduce((n, a) => n + (a.innerText || '').length, 0);
+			const adjusted = score * (1 - links / Math.max(1, textLength(el)));
+			if (adjusted > bestScore) {
+				bestScore = adjusted;
+				root = el;
+			}
+		});
+	}
+
+	if (!root) root = document.body;
+
+	// === 2. ПРЕОБРАЗОВАНИЕ В MARKDOWN ===
+	const clean = (s) => s.replace(/\s+/g, ' ');
+
+	const inlineNode = (node) => {
+		if (node.nodeType === Node.TEXT_NODE) return clean(node.textContent);
+		if (node.nodeType !== Node.ELEMENT_NODE) return '';
+
+		const tag = node.tagName.toLowerCase();
+		if (SKIP.has(tag) || !visible(node)) return '';
+
+		const inner = inline(node).trim();
+		switch (tag) {
+			case 'a': {
+				const href = node.href || '';
+				if (!inner || !href || href.startsWith('javascript:')) return inner;
+				return '[' + inner + '](' + href + ')';
+			}
+			case 'strong':
+			case 'b':
+				return inner ? '**' + inner + '** ' : '';
+			case 'em':
+			case 'i':
+				return inner ? '*' + inner + '* ' : '';
+			case 'code':
+				return inner ? '` + "`" + `' + inner + '` + "`" + `' : '';
+			case 'br':
+				return ' ';
+			case 'img':
+				return node.alt ? '[image: ' + clean(node.alt) + '] ' : '';
+			default:
+				return ' ' + inner + ' ';
+		}
+	};
+	const inline = (node) => Array.from(node.childNodes).map(inlineNode).join('');
+	const flat = (s) => clean(s).trim();
+
+	const list = (el, depth) => {
+		const lines = [];
+		let n = 1;
+		Array.from(el.children).forEach(li => {
+			if (li.tagName.toLowerCase() !== 'li' || !visible(li)) return;
+
+			const marker = el.tagName.toLowerCase() === 'ol' ? (n++) + '.' : '-';
+			const nested = [];
+			let text = '';
+			li.childNodes.forEach(c => {
+				if (c.nodeType === Node.ELEMENT_NODE && ['ul', 'ol'].includes(c.tagName.toLowerCase())) nested.push(c);
+				else text += inlineNode(c);
+			});
+
+			text = flat(text);
+			if (text) lines.push('  '.repeat(depth) + marker + ' ' + text);
+			nested.forEach(nl => lines.push(...list(nl, depth + 1)));
+		});
+		return lines;
+	};
+
+	const table = (el) => {
+		const rows = Array.from(el.rows)
+			.filter(visible)
+			.map(r => Array.from(r.cells).map(c => flat(inline(c)).replace(/\|/g, '\\|')));
+		if (!rows.length) return '';
+
+		const width = Math.max(...rows.map(r => r.length));
+		rows.forEach(r => { while (r.length < width) r.push(''); });
+
+		const lines = ['| ' + rows[0].join(' | ') + ' |', '|' + ' --- |'.repeat(width)];
+		rows.slice(1).forEach(r => lines.push('| ' + r.join(' | ') + ' |'));
+		return lines.join('\n');
+	};
+
+	const blocks = [];
+	const walk = (el) => {
+		let pending = '';
+		const flush = () => {
+			const t = flat(pending);
+			if (t) blocks.push(t);
+			pending = '';
+		};
+
+		el.childNodes.forEach(child => {
+			if (child.nodeType === Node.TEXT_NODE) {
+				pending += child.textContent;
+				return;
+			}
+			if (child.nodeType !== Node.ELEMENT_NODE) return;
+
+			const tag = child.tagName.toLowerCase();
+			if (SKIP.has(tag) || !visible(child)) return;
+			if (!BLOCK.test(tag)) {
+				pending += inlineNode(child);
+				return;
+			}
+
+			flush();
+			if (/^h[1-6]$/.test(tag)) {
+				const t = flat(inline(child));
+				if (t) blocks.push('#'.repeat(Number(tag[1])) + ' ' + t);
+			} else if (tag === 'ul' || tag === 'ol') {
+				const lines = list(child, 0);
+				if (lines.length) blocks.push(lines.join('\n'));
+			} else if (tag === 'table') {
+				const t = table(child);
+				if (t) blocks.push(t);
+			} else if (tag === 'pre') {
+				const t = child.innerText.trim();
+				if (t) blocks.push('` + "```" + `\n' + t + '\n` + "```" + `');
+			} else if (tag === 'blockquote') {
+				const t = flat(inline(child));
+				if (t) blocks.push('> ' + t);
+			} else if (tag === 'hr') {
+				blocks.push('---');
+			} else if (Array.from(child.children).some(c => BLOCK.test(c.tagName.toLowerCase()))) {
+				walk(child);
+			} else {
+				const t = flat(inline(child));
+				if (t) blocks.push(t);
+			}
+		});
+		flush();
+	};
+	walk(root);
+
+	return {markdown: blocks.join('\n\n')};
+}`
+
+// ReadPage извлекает основной контент страницы в Markdown и возвращает запрошенную
+// страницу пагинации (нумерация с 1).
+func (e *Extractor) ReadPage(ctx context.Context, page int) (*types.ReadableContent, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	info, err := e.page.Info()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page info: %w", err)
+	}
+
+	markdown, err := e.readMarkdown()
+	if err != nil {
+		return nil, err
+	}
+
+	pages := paginateMarkdown(markdown, readPageMaxTokens*bytesPerToken)
+	if page < 1 || page > len(pages) {
+		return nil, fmt.Errorf("content page %d out of range (1-%d)", page, len(pages))
+	}
+
+	return &types.ReadableContent{
+		Title:      info.Title,
+		URL:        info.URL,
+		Markdown:   pages[page-1],
+		Page:       page,
+		TotalPages: len(pages),
+	}, nil
+}
+
+// readMarkdown возвращает весь основной контент страницы в Markdown
+func (e *Extractor) readMarkdown() (string, error) {
+	res, err := e.page.Eval(readableScript)
+	if err != nil {
+		return "", fmt.Errorf("JS content extraction failed: %w", err)
+	}
+
+	var jsResult struct {
+		Markdown string `json:"markdown"`
+	}
+	if err := json.Unmarshal([]byte(res.Value.JSON("", "")), &jsResult); err != nil {
+		return "", fmt.Errorf("failed to parse JS result: %w", err)
+	}
+
+	return jsResult.Markdown, nil
+}
+
+func (e *Extractor) FormatReadableForLLM(content *types.ReadableContent) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("## Page: %s\n", content.Title))
+	b.WriteString(fmt.Sprintf("## URL: %s\n", content.URL))
+	b.WriteString(fmt.Sprintf("### Content page %d of %d\n\n", content.Page, content.TotalPages))
+
+	if content.Markdown == "" {
+		b.WriteString("(no readable content found, use extract_page to see interactive elements)\n")
+		return b.String()
+	}
+
+	b.WriteString(content.Markdown)
+	b.WriteString("\n")
+
+	if content.Page < content.TotalPages {
+		b.WriteString(fmt.Sprintf("\n(Call read_page with page=%d for the next part)\n", content.Page+1))
+	}
+
+	return b.String()
+}
+
+// paginateMarkdown режет Markdown на страницы не длиннее maxBytes по границам блоков.
+// Блок, который сам длиннее лимита, режется по последнему переводу строки или пробелу.
+func paginateMarkdown(md string, maxBytes int) []string {
+	var pages []string
+	var cur strings.Builder
+
+	flush := func() {
+		if cur.Len() > 0 {
+			pages = append(pages, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, block := range strings.Split(md, "\n\n") {
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+
+		for len(block) > maxBytes {
+			flush()
+			cut := splitPoint(block, maxBytes)
+			pages = append(pages, strings.TrimSpace(block[:cut]))
+			block = strings.TrimSpace(block[cut:])
+		}
+
+		if cur.Len() > 0 && cur.Len()+2+len(block) > maxBytes {
+			flush()
+		}
+		if cur.Len() > 0 {
+			cur.WriteString("\n\n")
+		}
+		cur.WriteString(block)
+	}
+	flush()
+
+	if len(pages) == 0 {
+		pages = []string{""}
+	}
+
+	return pages
+}
+
+// splitPoint возвращает позицию разреза не дальше limit, не разрывая UTF-8 символы
+func splitPoint(s string, limit int) int {
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+
+	if i := strings.LastIndex(s[:limit], "\n"); i > limit/2 {
+		return i + 1
+	}
+	if i := strings.LastIndex(s[:limit], " "); i > limit/2 {
+		return i + 1
+	}
+	return limit
+}
@@ -0,0 +1,91 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func TestPaginateMarkdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		md        string
+		maxBytes  int
+		wantPages int
+	}{
+		{
+			name:      "empty content",
+			md:        "",
+			maxBytes:  100,
+			wantPages: 1,
+		},
+		{
+			name:      "fits one page",
+			md:        "# Title\n\nFirst paragraph.\n\nSecond paragraph.",
+			maxBytes:  100,
+			wantPages: 1,
+		},
+		{
+			name:      "split by blocks",
+			md:        strings.Repeat("a", 40) + "\n\n" + strings.Repeat("b", 40) + "\n\n" + strings.Repeat("c", 40),
+			maxBytes:  50,
+			wantPages: 3,
+		},
+		{
+			name:      "long block is cut",
+			md:        strings.Repeat("word ", 50),
+			maxBytes:  100,
+			wantPages: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := paginateMarkdown(tt.md, tt.maxBytes)
+
+			if len(pages) != tt.wantPages {
+				t.Errorf("got %d pages, want %d: %q", len(pages), tt.wantPages, pages)
+			}
+			for i, p := range pages {
+				if len(p) > tt.maxBytes {
+					t.Errorf("page %d is %d bytes, limit %d", i+1, len(p), tt.maxBytes)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginateMarkdown_UTF8(t *testing.T) {
+	md := strings.Repeat("Привет", 30)
+
+	pages := paginateMarkdown(md, 25)
+
+	if strings.Join(pages, "") != md {
+		t.Error("expected pages to contain the whole content")
+	}
+	for i, p := range pages {
+		if !utf8.ValidString(p) {
+			t.Errorf("page %d is not valid UTF-8: %q", i+1, p)
+		}
+	}
+}
+
+func TestFormatReadableForLLM(t *testing.T) {
+	e := New(nil, nil)
+
+	out := e.FormatReadableForLLM(&types.ReadableContent{
+		Title:      "Docs",
+		URL:        "https://example.com/docs",
+		Markdown:   "# Intro\n\nText",
+		Page:       2,
+		TotalPages: 5,
+	})
+
+	for _, want := range []string{"### Content page 2 of 5", "# Intro", "read_page with page=3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// structuredMaxTokens ограничивает объём исходных данных для extract_structured
+const structuredMaxTokens = 6000
+
+// structuredDataScript собирает машиночитаемые данные страницы: JSON-LD и microdata
+const structuredDataScript = `() => {
+	const clean = (s) => (s || '').replace(/\s+/g, ' ').trim();
+
+	const jsonld = [];
+	document.querySelectorAll('script[type="application/ld+json"]').forEach(sc => {
+		try {
+			jsonld.push(JSON.parse(sc.textContent));
+		} catch (e) {}
+	});
+
+	const readItem = (scope) => {
+		const item = {};
+		if (scope.getAttribute('itemtype')) item['@type'] = scope.getAttribute('itemtype');
+
+		scope.querySelectorAll('[itemprop]').forEach(prop => {
+			// Свойства вложенных itemscope принадлежат им, а не текущему объекту
+			const owner = prop.parentElement ? prop.parentElement.closest('[itemscope]') : null;
+			if (owner !== scope) return;
+
+			const tag = prop.tagName.toLowerCase();
+			let value;
+			if (prop.hasAttribute('itemscope')) value = readItem(prop);
+			else if (prop.hasAttribute('content')) value = prop.getAttribute('content');
+			else if (tag === 'a' || tag === 'link') value = prop.href;
+			else if (['img', 'audio', 'video', 'source'].includes(tag)) value = prop.src;
+			else if (tag === 'time') value = prop.dateTime || clean(prop.innerText);
+			else if (tag === 'data' || tag === 'meter') value = prop.value;
+			else value = clean(prop.innerText || prop.textContent);
+
+			prop.getAttribute('itemprop').split(/\s+/).forEach(name => {
+				if (name in item) item[name] = [].concat(item[name], value);
+				else item[name] = value;
+			});
+		});
+		return item;
+	};
+
+	const microdata = Array.from(document.querySelectorAll('[itemscope]'))
+		.filter(el => !el.hasAttribute('itemprop'))
+		.slice(0, 50)
+		.map(readItem);
+
+	return {jsonld: jsonld, microdata: microdata};
+}`
+
+// StructuredSources собирает источники для extract_structured: JSON-LD, microdata,
+// строки таблиц и основной контент в Markdown. Результат обрезается по лимиту токенов,
+// машиночитаемые данные идут первыми как самые точные.
+func (e *Extractor) StructuredSources(ctx context.Context) (string, error) {
+	res, err := e.page.Eval(structuredDataScript)
+	if err != nil {
+		return "", fmt.Errorf("JS structured data extraction failed: %w", err)
+	}
+
+	var data struct {
+		JSONLD    []json.RawMessage `json:"jsonld"`
+		Microdata []json.RawMessage `json:"microdata"`
+	}
+	if err := json.Unmarshal([]byte(res.Value.JSON("", "")), &data); err != nil {
+		return "", fmt.Errorf("failed to parse JS result: %w", err)
+	}
+
+	tables, err := e.ExtractTables(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	markdown, err := e.readMarkdown()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	if len(data.JSONLD) > 0 {
+		b.WriteString("## JSON-LD\n")
+		for _, item := range data.JSONLD {
+			b.Write(item)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(data.Microdata) > 0 {
+		b.WriteString("## Microdata\n")
+		for _, item := range data.Microdata {
+			b.Write(item)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	for _, t := range tables {
+		b.WriteString(fmt.Sprintf("## Table %d (%s", t.Index, t.Kind))
+		if t.Caption != "" {
+			b.WriteString(fmt.Sprintf(" %q", t.Caption))
+		}
+		b.WriteString(")\n")
+		writeTableRows(&b, t, 0, len(t.Rows))
+		b.WriteString("\n")
+	}
+
+	if markdown != "" {
+		b.WriteString("## Page content\n")
+		b.WriteString(markdown)
+		b.WriteString("\n")
+	}
+
+	sources := b.String()
+	if limit := structuredMaxTokens * bytesPerToken; len(sources) > limit {
+		sources = sources[:splitPoint(sources, limit)] + "\n... (truncated)"
+	}
+
+	return sources, nil
+}
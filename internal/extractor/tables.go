@@ -0,0 +1,385 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// tableRowsPerPage — сколько строк таблицы отдаётся за один вызов extract_table
+const tableRowsPerPage = 30
+
+// tablesScript находит табличные данные: HTML-таблицы, ARIA-гриды и повторяющиеся
+// однотипные блоки (списки писем, результаты поиска, карточки товаров).
+// Для каждой строки возвращает ячейки со ссылками и ID интерактивных элементов из extract_page.
+const tablesScript = `() => {
+	const visible = (el) => {
+		const rect = el.getBoundingClientRect();
+		const style = window.getComputedStyle(el);
+		return rect.width > 0 && rect.height > 0 && style.display !== 'none' && style.visibility !== 'hidden';
+	};
+	const clean = (s) => (s || '').replace(/\s+/g, ' ').trim().substring(0, 200);
+	const links = (el) => {
+		const found = [];
+		if (el.tagName && el.tagName.toLowerCase() === 'a' && el.href) found.push(el.href);
+		el.querySelectorAll('a[href]').forEach(a => { if (!found.includes(a.href)) found.push(a.href); });
+		return found.filter(h => !h.startsWith('javascript:'));
+	};
+
+	// Интерактивные элементы строки, уже зарегистрированные extract_page
+	const actions = (row) => {
+		if (!window._ai_ids) return [];
+		const found = [];
+		row.querySelectorAll('a, button, input, select, [role="button"], [role="checkbox"], [role="link"], [title]').forEach(el => {
+			const id = window._ai_ids.get(el);
+			if (id === undefined || window._ai_elements[id] !== el) return;
+			const text = clean(el.innerText || el.value || el.getAttribute('title') || el.getAttribute('aria-label') || '').substring(0, 40);
+			found.push({id: id, text: text});
+		});
+		return found;
+	};
+
+	const tables = [];
+	const taken = [];
+	const isTaken = (el) => taken.some(t => t === el || t.contains(el) || el.contains(t));
+
+	// === 1. HTML-ТАБЛИЦЫ ===
+	document.querySelectorAll('table').forEach(table => {
+		if (!visible(table) || table.querySelector('table')) return;
+
+		const rows = Array.from(table.rows).filter(visible);
+		if (rows.length < 2) return;
+
+		let headers = [];
+		let body = rows;
+		const headRow = table.tHead && table.tHead.rows.length ? table.tHead.rows[0] : null;
+		if (headRow) {
+			headers = Array.from(headRow.cells).map(c => clean(c.innerText));
+			body = rows.filter(r => r.parentElement !== table.tHead);
+		} else if (Array.from(rows[0].cells).every(c => c.tagName.toLowerCase() === 'th')) {
+			headers = Array.from(rows[0].cells).map(c => clean(c.innerText));
+			body = rows.slice(1);
+		}
+
+		taken.push(table);
+		tables.push({
+			kind: 'table',
+			caption: clean(table.caption ? table.caption.innerText : (table.getAttribute('aria-label') || '')),
+			headers: headers,
+			rows: body.map(r => ({
+				cells: Array.from(r.cells).map(c => ({text: clean(c.innerText), links: links(c)})),
+				actions: actions(r)
+			}))
+		});
+	});
+
+	// === 2. ARIA-ГРИДЫ ===
+	document.querySelectorAll('[role="grid"], [role="table"], [role="treegrid"]').forEach(grid => {
+		if (!visible(grid) || isTaken(grid)) return;
+
+		const rows = Array.from(grid.querySelectorAll('[role="row"]')).filter(visible);
+		if (rows.length < 2) return;
+
+		const cellsOf = (row) => Array.from(row.querySelectorAll('[role="cell"], [role="gridcell"], [role="columnheader"], [role="rowheader"]'));
+		let headers = [];
+		let body = rows;
+		const headerCells = cellsOf(rows[0]).filter(c => c.getAttribute('role') === 'columnheader');
+		if (headerCells.length) {
+			headers = headerCells.map(c => clean(c.innerText));
+			body = rows.slice(1);
+		}
+
+		taken.push(grid);
+		tables.push({
+			kind: 'grid',
+			caption: clean(grid.getAttribute('aria-label') || ''),
+			headers: headers,
+			rows: body.map(r => {
+				const cells = cellsOf(r);
+				return {
+					// Строка без размеченных ячеек — одна ячейка с её текстом
+					cells: (cells.length ? cells : [r]).map(c => ({text: clean(c.innerText), links: links(c)})),
+					actions: actions(r)
+				};
+			})
+		});
+	});
+
+	// === 3. ПОВТОРЯЮЩИЕСЯ БЛОКИ ===
+	const signature = (el) => el.tagName.toLowerCase() + '.' + Array.from(el.classList).slice(0, 2).sort().join('.');
+	const candidates = [];
+	document.querySelectorAll('body *').forEach(parent => {
+		if (parent.children.length < 3 || ['nav', 'header', 'footer', 'select', 'script'].includes(parent.tagName.toLowerCase())) return;
+		if (parent.closest('nav, header, footer')) return;
+
+		const groups = new Map();
+		Array.from(parent.children).forEach(child => {
+			const sig = signature(child);
+			if (!groups.has(sig)) groups.set(sig, []);
+			groups.get(sig).push(child);
+		});
+
+		groups.forEach(items => {
+			items = items.filter(visible);
+			if (items.length < 3 || items.length < parent.children.length * 0.6) return;
+			const avg = items.reduce((n, it) => n + (it.innerText || '').trim().length, 0) / items.length;
+			if (avg < 20) return;
+			candidates.push({parent: parent, items: items, score: items.length * Math.min(avg, 300)});
+		});
+	});
+
+	candidates.sort((a, b) => b.score - a.score);
+	candidates.forEach(cand => {
+		if (tables.length >= 20 || isTaken(cand.parent)) return;
+
+		// Поля записи — элементы с собственным текстом, ключ — тег и первый класс
+		const records = cand.items.map(item => {
+			const fields = new Map();
+			const counts = {};
+			item.querySelectorAll('*').forEach(el => {
+				const own = Array.from(el.childNodes).filter(n => n.nodeType === Node.TEXT_NODE).map(n => n.textContent).join(' ');
+				const text = clean(own);
+				if (!text || !visible(el)) return;
+				const base = el.tagName.toLowerCase() + (el.classList.length ? '.' + el.classList[0] : '');
+				counts[base] = (counts[base] || 0) + 1;
+				const key = counts[base] > 1 ? base + '#' + counts[base] : base;
+				const link = el.closest('a');
+				fields.set(key, {text: text, links: link && link.href ? [link.href] : []});
+			});
+			return fields;
+		});
+
+		// Колонки — поля, которые есть хотя бы у половины записей
+		const freq = new Map();
+		records.forEach(r => r.forEach((_, key) => freq.set(key, (freq.get(key) || 0) + 1)));
+		const columns = Array.from(freq.keys()).filter(k => freq.get(k) >= records.length / 2).slice(0, 12);
+		if (!columns.length) return;
+
+		taken.push(cand.parent);
+		tables.push({
+			kind: 'list',
+			caption: clean(cand.parent.getAttribute('aria-label') || ''),
+			headers: columns.map((key, i) => {
+				const cls = key.split('.')[1];
+				return cls ? cls.split('#')[0] : 'field ' + (i + 1);
+			}),
+			rows: cand.items.map((item, i) => ({
+				cells: columns.map(key => records[i].get(key) || {text: '', links: []}),
+				actions: actions(item)
+			}))
+		});
+	});
+
+	return {tables: tables};
+}`
+
+// ExtractTables находит на странице табличные данные и возвращает их со всеми строками
+func (e *Extractor) ExtractTables(ctx context.Context) ([]types.Table, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	res, err := e.page.Eval(tablesScript)
+	if err != nil {
+		return nil, fmt.Errorf("JS table extraction failed: %w", err)
+	}
+
+	var jsResult struct {
+		Tables []struct {
+			Kind    string   `json:"kind"`
+			Caption string   `json:"caption"`
+			Headers []string `json:"headers"`
+			Rows    []struct {
+				Cells []struct {
+					Text  string   `json:"text"`
+					Links []string `json:"links"`
+				} `json:"cells"`
+				Actions []struct {
+					ID   int    `json:"id"`
+					Text string `json:"text"`
+				} `json:"actions"`
+			} `json:"rows"`
+		} `json:"tables"`
+	}
+	if err := json.Unmarshal([]byte(res.Value.JSON("", "")), &jsResult); err != nil {
+		return nil, fmt.Errorf("failed to parse JS result: %w", err)
+	}
+
+	tables := make([]types.Table, 0, len(jsResult.Tables))
+	for i, t := range jsResult.Tables {
+		table := types.Table{
+			Index:   i,
+			Kind:    t.Kind,
+			Caption: t.Caption,
+		}
+
+		width := len(t.Headers)
+		for _, r := range t.Rows {
+			row := types.TableRow{}
+			for _, c := range r.Cells {
+				row.Cells = append(row.Cells, types.TableCell{Text: c.Text, Links: c.Links})
+			}
+			for _, a := range r.Actions {
+				row.Actions = append(row.Actions, types.RowAction{ElementID: a.ID, Text: a.Text})
+			}
+			width = max(width, len(row.Cells))
+			table.Rows = append(table.Rows, row)
+		}
+
+		// Недостающие заголовки заполняем номерами колонок
+		table.Headers = t.Headers
+		for len(table.Headers) < width {
+			table.Headers = append(table.Headers, fmt.Sprintf("col%d", len(table.Headers)+1))
+		}
+
+		table.ColumnTypes = make([]string, width)
+		for col := range width {
+			values := make([]string, 0, len(table.Rows))
+			for _, row := range table.Rows {
+				if col < len(row.Cells) {
+					values = append(values, row.Cells[col].Text)
+				}
+			}
+			table.ColumnTypes[col] = inferColumnType(values)
+		}
+
+		tables = append(tables, table)
+	}
+
+	if e.logger != nil {
+		e.logger.Debug("Extracted tables", "count", len(tables))
+	}
+
+	return tables, nil
+}
+
+var (
+	numberRe = regexp.MustCompile(`^[-+]?[\d\s\x{00a0}]*[.,]?\d+\s*(%|₽|\$|€|руб\.?|rub|usd|eur)?$`)
+	dateRe   = regexp.MustCompile(`(?i)^(\d{1,4}[./-]\d{1,2}[./-]\d{1,4}|\d{1,2}:\d{2}|\d{1,2}\s+\p{L}{3,}\.?(\s+\d{2,4})?|\p{L}{3,}\.?\s+\d{1,2}(,?\s+\d{4})?)$`)
+)
+
+// inferColumnType определяет тип колонки по значениям: number, date или text.
+// Пустые ячейки не учитываются.
+func inferColumnType(values []string) string {
+	numbers, dates, total := 0, 0, 0
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		total++
+
+		if numberRe.MatchString(strings.ToLower(strings.TrimLeft(v, "$€₽"))) {
+			numbers++
+		} else if dateRe.MatchString(v) {
+			dates++
+		}
+	}
+
+	switch {
+	case total == 0:
+		return "text"
+	case numbers == total:
+		return "number"
+	case dates == total:
+		return "date"
+	default:
+		return "text"
+	}
+}
+
+// FormatTablesForLLM выводит список найденных таблиц и запрошенную страницу строк одной из них
+func (e *Extractor) FormatTablesForLLM(tables []types.Table, index, page int) string {
+	var b strings.Builder
+
+	if len(tables) == 0 {
+		b.WriteString("No tables, grids or repeated lists found on the page. Try read_page or extract_page.\n")
+		return b.String()
+	}
+
+	b.WriteString(fmt.Sprintf("## Tables on page: %d\n", len(tables)))
+	for _, t := range tables {
+		line := fmt.Sprintf("[%d] %s", t.Index, t.Kind)
+		if t.Caption != "" {
+			line += fmt.Sprintf(" %q", t.Caption)
+		}
+		line += fmt.Sprintf(" - %d rows, columns: %s", len(t.Rows), strings.Join(t.Headers, ", "))
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n")
+
+	if index < 0 || index >= len(tables) {
+		b.WriteString(fmt.Sprintf("Table %d not found (use 0-%d).\n", index, len(tables)-1))
+		return b.String()
+	}
+
+	t := tables[index]
+	totalPages := max(1, (len(t.Rows)+tableRowsPerPage-1)/tableRowsPerPage)
+	if page < 1 || page > totalPages {
+		b.WriteString(fmt.Sprintf("Rows page %d out of range (1-%d).\n", page, totalPages))
+		return b.String()
+	}
+
+	from := (page - 1) * tableRowsPerPage
+	to := min(from+tableRowsPerPage, len(t.Rows))
+	b.WriteString(fmt.Sprintf("### Table %d: rows %d-%d of %d (page %d of %d)\n", index, from+1, to, len(t.Rows), page, totalPages))
+
+	writeTableRows(&b, t, from, to)
+
+	if page < totalPages {
+		b.WriteString(fmt.Sprintf("\n(Call extract_table with table=%d, page=%d for more rows)\n", index, page+1))
+	}
+
+	return b.String()
+}
+
+// writeTableRows выводит строки таблицы [from, to) в Markdown с колонками действий
+func writeTableRows(b *strings.Builder, t types.Table, from, to int) {
+	headers := make([]string, len(t.Headers))
+	for i, h := range t.Headers {
+		headers[i] = h
+		if i < len(t.ColumnTypes) && t.ColumnTypes[i] != "text" {
+			headers[i] += " (" + t.ColumnTypes[i] + ")"
+		}
+	}
+	b.WriteString("| # | " + strings.Join(headers, " | ") + " | actions |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(headers)+2) + "\n")
+
+	for i, row := range t.Rows[from:to] {
+		cells := make([]string, len(t.Headers))
+		for j := range cells {
+			if j < len(row.Cells) {
+				cells[j] = formatCell(row.Cells[j])
+			}
+		}
+
+		actions := make([]string, 0, len(row.Actions))
+		for _, a := range row.Actions {
+			action := fmt.Sprintf("[%d]", a.ElementID)
+			if a.Text != "" {
+				action += " " + a.Text
+			}
+			actions = append(actions, action)
+		}
+
+		b.WriteString(fmt.Sprintf("| %d | %s | %s |\n", from+i+1, strings.Join(cells, " | "), strings.Join(actions, ", ")))
+	}
+}
+
+func formatCell(c types.TableCell) string {
+	text := strings.ReplaceAll(c.Text, "|", "\\|")
+	switch {
+	case len(c.Links) == 0:
+		return text
+	case len(c.Links) == 1 && text != "":
+		return "[" + text + "](" + c.Links[0] + ")"
+	default:
+		return text + " (" + strings.Join(c.Links, ", ") + ")"
+	}
+}
@@ -0,0 +1,84 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func TestInferColumnType(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"integers", []string{"1", "25", "300"}, "number"},
+		{"prices", []string{"1 500 ₽", "$20", "99,90"}, "number"},
+		{"percent", []string{"15%", "-3.5%"}, "number"},
+		{"dates", []string{"2024-01-29", "29.01.2024", "12:45"}, "date"},
+		{"month names", []string{"Jan 29", "29 янв", "March 3, 2024"}, "date"},
+		{"mixed", []string{"1", "hello"}, "text"},
+		{"empty cells ignored", []string{"", "42", ""}, "number"},
+		{"all empty", []string{"", ""}, "text"},
+		{"text", []string{"Amazon", "Your order shipped"}, "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferColumnType(tt.values); got != tt.want {
+				t.Errorf("inferColumnType(%q) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTablesForLLM(t *testing.T) {
+	e := New(nil, nil)
+
+	rows := make([]types.TableRow, 35)
+	for i := range rows {
+		rows[i] = types.TableRow{
+			Cells: []types.TableCell{
+				{Text: "Sender", Links: []string{"https://mail.example.com/1"}},
+				{Text: "10"},
+			},
+			Actions: []types.RowAction{{ElementID: 7, Text: "Delete"}},
+		}
+	}
+	tables := []types.Table{{
+		Index:       0,
+		Kind:        "list",
+		Headers:     []string{"from", "size"},
+		ColumnTypes: []string{"text", "number"},
+		Rows:        rows,
+	}}
+
+	out := e.FormatTablesForLLM(tables, 0, 1)
+	for _, want := range []string{
+		"[0] list - 35 rows, columns: from, size",
+		"### Table 0: rows 1-30 of 35 (page 1 of 2)",
+		"| # | from | size (number) | actions |",
+		"| 1 | [Sender](https://mail.example.com/1) | 10 | [7] Delete |",
+		"extract_table with table=0, page=2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	out = e.FormatTablesForLLM(tables, 0, 2)
+	if !strings.Contains(out, "rows 31-35 of 35 (page 2 of 2)") {
+		t.Errorf("unexpected second page:\n%s", out)
+	}
+
+	out = e.FormatTablesForLLM(tables, 3, 1)
+	if !strings.Contains(out, "Table 3 not found") {
+		t.Errorf("expected not found message, got:\n%s", out)
+	}
+
+	out = e.FormatTablesForLLM(nil, 0, 1)
+	if !strings.Contains(out, "No tables") {
+		t.Errorf("expected empty message, got:\n%s", out)
+	}
+}
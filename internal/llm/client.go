@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
@@ -16,11 +19,19 @@ type Client struct {
 	model      string
 	logger     *logger.Logger
 	maxRetries int
+	vision     bool
 }
 
 func NewClient(config *types.LLMConfig, log *logger.Logger) (*Client, error) {
 	cfg := openai.DefaultConfig(config.APIKey)
 	cfg.BaseURL = config.BaseURL
+	if config.Proxy != "" {
+		httpClient, err := proxyHTTPClient(config.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		cfg.HTTPClient = httpClient
+	}
 
 	client := openai.NewClientWithConfig(cfg)
 
@@ -34,18 +45,75 @@ func NewClient(config *types.LLMConfig, log *logger.Logger) (*Client, error) {
 		model:      config.Model,
 		logger:     log,
 		maxRetries: maxRetries,
+		vision:     config.Vision,
 	}, nil
 }
 
+// proxyHTTPClient возвращает HTTP-клиент, который ходит к модели через прокси или, для "direct",
+// напрямую в обход переменных окружения
+func proxyHTTPClient(proxy string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if strings.EqualFold(strings.TrimSpace(proxy), "direct") {
+		transport.Proxy = nil
+		return &http.Client{Transport: transport}, nil
+	}
+
+	raw := strings.TrimSpace(proxy)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported LLM proxy scheme %q (use http, https or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("LLM proxy URL has no host")
+	}
+
+	transport.Proxy = http.ProxyURL(u)
+	return &http.Client{Transport: transport}, nil
+}
+
 func (c *Client) Chat(ctx context.Context, messages []openai.ChatCompletionMessage) (*openai.ChatCompletionResponse, error) {
 	c.logger.Thinking()
 
-	req := openai.ChatCompletionRequest{
+	return c.createWithRetry(ctx, openai.ChatCompletionRequest{
 		Model:    c.model,
 		Messages: messages,
 		Tools:    GetTools(),
+	})
+}
+
+// CompleteJSON запрашивает у модели ответ без инструментов и возвращает его как JSON.
+// Обрамление ```json ... ``` снимается, невалидный JSON считается ошибкой.
+func (c *Client) CompleteJSON(ctx context.Context, messages []openai.ChatCompletionMessage) (json.RawMessage, error) {
+	resp, err := c.createWithRetry(ctx, openai.ChatCompletionRequest{
+		Model:    c.model,
+		Messages: messages,
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response from model")
+	}
+
+	content := stripCodeFence(resp.Choices[0].Message.Content)
+	if !json.Valid([]byte(content)) {
+		return nil, fmt.Errorf("model returned invalid JSON: %s", truncate(content, 200))
+	}
+
+	return json.RawMessage(content), nil
+}
+
+func (c *Client) createWithRetry(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
 	var resp openai.ChatCompletionResponse
 	var lastErr error
 
@@ -87,6 +155,28 @@ func (c *Client) Chat(ctx context.Context, messages []openai.ChatCompletionMessa
 	return nil, fmt.Errorf("chat completion failed after %d retries: %w", c.maxRetries, lastErr)
 }
 
+// stripCodeFence убирает markdown-обрамление, в которое модели часто заворачивают JSON
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[i+1:]
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
 func (c *Client) ExtractToolCall(response *openai.ChatCompletionResponse) (*types.ToolCall, bool) {
 	if len(response.Choices) == 0 {
 		return nil, false
@@ -112,6 +202,11 @@ func (c *Client) ExtractToolCall(response *openai.ChatCompletionResponse) (*type
 	}, true
 }
 
+// SupportsVision сообщает, можно ли отправлять модели изображения
+func (c *Client) SupportsVision() bool {
+	return c.vision
+}
+
 func (c *Client) GetModel() string {
 	return c.model
 }
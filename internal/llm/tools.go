@@ -1,6 +1,9 @@
 package llm
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -10,11 +13,91 @@ func GetTools() []openai.Tool {
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
 				Name:        "extract_page",
-				Description: "Get current page state with all interactive elements. ALWAYS call this first and after any action to see the result.",
+				Description: "Get current page state with all interactive elements. ALWAYS call this first and after any action to see the result. On the same URL only changes since the previous call are returned.",
 				Parameters: map[string]interface{}{
-					"type":       "object",
-					"properties": map[string]interface{}{},
-					"required":   []string{},
+					"type": "object",
+					"properties": map[string]interface{}{
+						"full": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Return the complete element list instead of changes since the previous call",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "read_page",
+				Description: "Read the main content of the page (article, docs, product description) as Markdown with headings, lists, tables and links. Long content is split into pages.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"page": map[string]interface{}{
+							"type":        "integer",
+							"description": "Content page number, starting from 1 (default 1)",
+							"minimum":     1,
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "extract_table",
+				Description: "Extract structured rows from tables, grids and repeated lists (emails, search results, price lists). Returns all tables found and the rows of the selected one with column headers, links and element IDs of row actions.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"table": map[string]interface{}{
+							"type":        "integer",
+							"description": "Index of the table to show rows of (default 0)",
+							"minimum":     0,
+						},
+						"page": map[string]interface{}{
+							"type":        "integer",
+							"description": "Rows page number, starting from 1 (default 1)",
+							"minimum":     1,
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "extract_structured",
+				Description: "Extract data from the current page as JSON matching a JSON Schema. Uses page content, table rows and JSON-LD/microdata. Without a schema the task output schema is used.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type":        "string",
+							"description": "JSON Schema of the data as a JSON string (default: the task output schema)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "screenshot",
+				Description: "Take a screenshot of the visible page or of one element. Use for canvas apps, charts, image captchas and icon-only buttons that extract_page cannot describe.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"element_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "ID of the element to crop the screenshot to (default: whole visible page)",
+						},
+					},
+					"required": []string{},
 				},
 			},
 		},
@@ -35,6 +118,105 @@ func GetTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "go_back",
+				Description: "Go back to the previous page in the history of the active tab (like the browser Back button). Useful after opening a search result or list item.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "go_forward",
+				Description: "Go forward to the next page in the history of the active tab (after go_back).",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "reload",
+				Description: "Reload the active tab, e.g. when the page did not load completely or shows stale data.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "list_tabs",
+				Description: "List open browser tabs with their IDs, titles and URLs. The active tab is marked with *.",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "switch_tab",
+				Description: "Make a tab active. All following actions and extract_page work in the active tab.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"tab_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "ID of the tab from list_tabs",
+						},
+					},
+					"required": []string{"tab_id"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "open_tab",
+				Description: "Open a URL in a new tab and make it active. The current tab stays open.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"url": map[string]interface{}{
+							"type":        "string",
+							"description": "The URL to open",
+						},
+					},
+					"required": []string{"url"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "close_tab",
+				Description: "Close a tab. The last tab cannot be closed.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"tab_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "ID of the tab to close (default: the active tab)",
+						},
+					},
+					"required": []string{},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
@@ -52,11 +234,107 @@ func GetTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "hover",
+				Description: "Move the mouse over an element by its ID to open a hover menu, submenu or tooltip",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"element_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "The ID of element to hover, e.g. 5 for [5]",
+						},
+					},
+					"required": []string{"element_id"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "select_option",
+				Description: "Choose an option of a <select> dropdown by its value or visible label. extract_page lists the options of each select.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"element_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "The ID of the select element",
+						},
+						"value": map[string]interface{}{
+							"type":        "string",
+							"description": "Option value attribute",
+						},
+						"label": map[string]interface{}{
+							"type":        "string",
+							"description": "Visible option text (exact or a part of it)",
+						},
+					},
+					"required": []string{"element_id"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "set_checked",
+				Description: "Set a checkbox, radio button or switch to a known state. Does nothing if it is already in that state.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"element_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "The ID of the checkbox, radio button or switch",
+						},
+						"checked": map[string]interface{}{
+							"type":        "boolean",
+							"description": "true to check, false to uncheck",
+						},
+					},
+					"required": []string{"element_id", "checked"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "upload_file",
+				Description: "Attach a file from the user's workspace directory to a file upload field. Pass the ID of the file input or of its upload button.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"element_id": map[string]interface{}{
+							"type":        "integer",
+							"description": "The ID of the file input or the upload/attach button",
+						},
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "File path relative to the workspace directory, e.g. cv.pdf",
+						},
+					},
+					"required": []string{"element_id", "path"},
+				},
+			},
+		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "list_downloads",
+				Description: "List files downloaded in this task with name, size, MIME type, state and saved path",
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{},
+					"required":   []string{},
+				},
+			},
+		},
 		{
 			Type: openai.ToolTypeFunction,
 			Function: &openai.FunctionDefinition{
 				Name:        "type_text",
-				Description: "Type text into an input field by its ID",
+				Description: "Type text into an input field by its ID. Replaces the current value. For date/time inputs pass YYYY-MM-DD / HH:MM, for range inputs a number.",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -159,6 +437,10 @@ func GetTools() []openai.Tool {
 							"type":        "boolean",
 							"description": "Whether the operation was successful",
 						},
+						"data": map[string]interface{}{
+							"type":        "string",
+							"description": "Structured result as a JSON string. Required when the task defines an output schema; must match it",
+						},
 					},
 					"required": []string{"message", "success"},
 				},
@@ -181,17 +463,91 @@ func GetTools() []openai.Tool {
 				},
 			},
 		},
+		{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        "handle_dialog",
+				Description: "Answer a JavaScript dialog (alert, confirm, prompt, beforeunload) that blocks the page. extract_page shows it as \"Dialog\".",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"accept": map[string]interface{}{
+							"type":        "boolean",
+							"description": "true presses OK (or leaves the page for beforeunload), false presses Cancel",
+						},
+						"text": map[string]interface{}{
+							"type":        "string",
+							"description": "Answer for a prompt dialog",
+						},
+					},
+					"required": []string{"accept"},
+				},
+			},
+		},
 	}
 }
 
+type ExtractPageInput struct {
+	Full bool `json:"full"`
+}
+
+type ReadPageInput struct {
+	Page int `json:"page"`
+}
+
+type ExtractTableInput struct {
+	Table int `json:"table"`
+	Page  int `json:"page"`
+}
+
+type ExtractStructuredInput struct {
+	Schema string `json:"schema"`
+}
+
+type ScreenshotInput struct {
+	ElementID *int `json:"element_id"`
+}
+
 type NavigateInput struct {
 	URL string `json:"url"`
 }
 
+type SwitchTabInput struct {
+	TabID int `json:"tab_id"`
+}
+
+type OpenTabInput struct {
+	URL string `json:"url"`
+}
+
+type CloseTabInput struct {
+	TabID *int `json:"tab_id"`
+}
+
 type ClickInput struct {
 	ElementID int `json:"element_id"`
 }
 
+type HoverInput struct {
+	ElementID int `json:"element_id"`
+}
+
+type SelectOptionInput struct {
+	ElementID int    `json:"element_id"`
+	Value     string `json:"value"`
+	Label     string `json:"label"`
+}
+
+type SetCheckedInput struct {
+	ElementID int  `json:"element_id"`
+	Checked   bool `json:"checked"`
+}
+
+type UploadFileInput struct {
+	ElementID int    `json:"element_id"`
+	Path      string `json:"path"`
+}
+
 type TypeTextInput struct {
 	ElementID int    `json:"element_id"`
 	Text      string `json:"text"`
@@ -214,10 +570,41 @@ type ConfirmActionInput struct {
 }
 
 type ReportInput struct {
-	Message string `json:"message"`
-	Success bool   `json:"success"`
+	Message string   `json:"message"`
+	Success bool     `json:"success"`
+	Data    JSONData `json:"data"`
+}
+
+// JSONData принимает JSON как строкой, так и вложенным значением: модели
+// передают data по-разному, несмотря на объявленный тип string.
+type JSONData json.RawMessage
+
+func (d *JSONData) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		if s == "" {
+			*d = nil
+			return nil
+		}
+		if !json.Valid([]byte(s)) {
+			return fmt.Errorf("data is not valid JSON")
+		}
+		*d = JSONData(s)
+		return nil
+	}
+	if string(b) == "null" {
+		*d = nil
+		return nil
+	}
+	*d = append((*d)[:0], b...)
+	return nil
 }
 
 type PressKeyInput struct {
 	Key string `json:"key"`
 }
+
+type HandleDialogInput struct {
+	Accept bool   `json:"accept"`
+	Text   string `json:"text"`
+}
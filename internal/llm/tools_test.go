@@ -473,3 +473,373 @@ func TestParseReportInput(t *testing.T) {
 		})
 	}
 }
+
+func TestParseExtractPageInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantError bool
+		wantFull  bool
+	}{
+		{
+			name:      "full requested",
+			input:     `{"full": true}`,
+			wantError: false,
+			wantFull:  true,
+		},
+		{
+			name:      "empty object",
+			input:     `{}`,
+			wantError: false,
+			wantFull:  false,
+		},
+		{
+			name:      "string instead of bool",
+			input:     `{"full": "yes"}`,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params ExtractPageInput
+			err := json.Unmarshal([]byte(tt.input), &params)
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if params.Full != tt.wantFull {
+				t.Errorf("got Full=%v, want %v", params.Full, tt.wantFull)
+			}
+		})
+	}
+}
+
+func TestParseReadPageInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantError bool
+		wantPage  int
+	}{
+		{
+			name:      "page number",
+			input:     `{"page": 3}`,
+			wantError: false,
+			wantPage:  3,
+		},
+		{
+			name:      "missing page field",
+			input:     `{}`,
+			wantError: false,
+			wantPage:  0,
+		},
+		{
+			name:      "string instead of int",
+			input:     `{"page": "2"}`,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params ReadPageInput
+			err := json.Unmarshal([]byte(tt.input), &params)
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if params.Page != tt.wantPage {
+				t.Errorf("got Page=%d, want %d", params.Page, tt.wantPage)
+			}
+		})
+	}
+}
+
+func TestParseExtractTableInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantError bool
+		wantTable int
+		wantPage  int
+	}{
+		{
+			name:      "table and page",
+			input:     `{"table": 2, "page": 3}`,
+			wantError: false,
+			wantTable: 2,
+			wantPage:  3,
+		},
+		{
+			name:      "empty object",
+			input:     `{}`,
+			wantError: false,
+			wantTable: 0,
+			wantPage:  0,
+		},
+		{
+			name:      "string instead of int",
+			input:     `{"table": "first"}`,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params ExtractTableInput
+			err := json.Unmarshal([]byte(tt.input), &params)
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if params.Table != tt.wantTable || params.Page != tt.wantPage {
+				t.Errorf("got Table=%d Page=%d, want %d %d", params.Table, params.Page, tt.wantTable, tt.wantPage)
+			}
+		})
+	}
+}
+
+func TestParseReportInput_Data(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantError bool
+		wantData  string
+	}{
+		{
+			name:     "data as JSON string",
+			input:    `{"message": "Done", "success": true, "data": "[{\"title\": \"Go developer\"}]"}`,
+			wantData: `[{"title": "Go developer"}]`,
+		},
+		{
+			name:     "data as object",
+			input:    `{"message": "Done", "success": true, "data": {"count": 2}}`,
+			wantData: `{"count": 2}`,
+		},
+		{
+			name:     "empty string",
+			input:    `{"message": "Done", "success": true, "data": ""}`,
+			wantData: "",
+		},
+		{
+			name:     "null",
+			input:    `{"message": "Done", "success": true, "data": null}`,
+			wantData: "",
+		},
+		{
+			name:      "string with invalid JSON",
+			input:     `{"message": "Done", "success": true, "data": "[{"}`,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params ReportInput
+			err := json.Unmarshal([]byte(tt.input), &params)
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if string(params.Data) != tt.wantData {
+				t.Errorf("got Data=%s, want %s", params.Data, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestParseExtractStructuredInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantError  bool
+		wantSchema string
+	}{
+		{
+			name:       "schema string",
+			input:      `{"schema": "{\"type\": \"array\"}"}`,
+			wantSchema: `{"type": "array"}`,
+		},
+		{
+			name:       "empty object",
+			input:      `{}`,
+			wantSchema: "",
+		},
+		{
+			name:      "number instead of string",
+			input:     `{"schema": 1}`,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params ExtractStructuredInput
+			err := json.Unmarshal([]byte(tt.input), &params)
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if params.Schema != tt.wantSchema {
+				t.Errorf("got Schema=%q, want %q", params.Schema, tt.wantSchema)
+			}
+		})
+	}
+}
+
+func TestParseScreenshotInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantError bool
+		wantID    *int
+	}{
+		{
+			name:   "element",
+			input:  `{"element_id": 7}`,
+			wantID: intPtr(7),
+		},
+		{
+			name:   "element zero",
+			input:  `{"element_id": 0}`,
+			wantID: intPtr(0),
+		},
+		{
+			name:   "whole page",
+			input:  `{}`,
+			wantID: nil,
+		},
+		{
+			name:      "string instead of int",
+			input:     `{"element_id": "7"}`,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params ScreenshotInput
+			err := json.Unmarshal([]byte(tt.input), &params)
+
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if (params.ElementID == nil) != (tt.wantID == nil) ||
+				(params.ElementID != nil && *params.ElementID != *tt.wantID) {
+				t.Errorf("got ElementID=%v, want %v", params.ElementID, tt.wantID)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestParseTabInputs(t *testing.T) {
+	var sw SwitchTabInput
+	if err := json.Unmarshal([]byte(`{"tab_id": 2}`), &sw); err != nil || sw.TabID != 2 {
+		t.Errorf("switch_tab: got %+v, %v", sw, err)
+	}
+	if err := json.Unmarshal([]byte(`{"tab_id": "2"}`), &sw); err == nil {
+		t.Error("switch_tab: expected error for string tab_id")
+	}
+
+	var open OpenTabInput
+	if err := json.Unmarshal([]byte(`{"url": "https://example.com"}`), &open); err != nil || open.URL != "https://example.com" {
+		t.Errorf("open_tab: got %+v, %v", open, err)
+	}
+
+	var closeTab CloseTabInput
+	if err := json.Unmarshal([]byte(`{}`), &closeTab); err != nil || closeTab.TabID != nil {
+		t.Errorf("close_tab without id: got %+v, %v", closeTab, err)
+	}
+	if err := json.Unmarshal([]byte(`{"tab_id": 3}`), &closeTab); err != nil || closeTab.TabID == nil || *closeTab.TabID != 3 {
+		t.Errorf("close_tab with id: got %+v, %v", closeTab, err)
+	}
+}
+
+func TestParseHoverInput(t *testing.T) {
+	var input HoverInput
+	if err := json.Unmarshal([]byte(`{"element_id": 7}`), &input); err != nil || input.ElementID != 7 {
+		t.Errorf("hover: got %+v, %v", input, err)
+	}
+	if err := json.Unmarshal([]byte(`{"element_id": "7"}`), &input); err == nil {
+		t.Error("hover: expected error for string element_id")
+	}
+}
+
+func TestParseFormControlInputs(t *testing.T) {
+	var sel SelectOptionInput
+	if err := json.Unmarshal([]byte(`{"element_id": 4, "label": "Russia"}`), &sel); err != nil || sel.ElementID != 4 || sel.Label != "Russia" || sel.Value != "" {
+		t.Errorf("select_option: got %+v, %v", sel, err)
+	}
+
+	var chk SetCheckedInput
+	if err := json.Unmarshal([]byte(`{"element_id": 2, "checked": true}`), &chk); err != nil || chk.ElementID != 2 || !chk.Checked {
+		t.Errorf("set_checked: got %+v, %v", chk, err)
+	}
+	if err := json.Unmarshal([]byte(`{"element_id": 2, "checked": "yes"}`), &chk); err == nil {
+		t.Error("set_checked: expected error for string checked")
+	}
+}
+
+func TestParseUploadFileInput(t *testing.T) {
+	var input UploadFileInput
+	if err := json.Unmarshal([]byte(`{"element_id": 12, "path": "docs/cv.pdf"}`), &input); err != nil || input.ElementID != 12 || input.Path != "docs/cv.pdf" {
+		t.Errorf("upload_file: got %+v, %v", input, err)
+	}
+}
+
+func TestParseHandleDialogInput(t *testing.T) {
+	var input HandleDialogInput
+	if err := json.Unmarshal([]byte(`{"accept": true, "text": "42"}`), &input); err != nil || !input.Accept || input.Text != "42" {
+		t.Errorf("handle_dialog: got %+v, %v", input, err)
+	}
+	if err := json.Unmarshal([]byte(`{"accept": "no"}`), &input); err == nil {
+		t.Error("handle_dialog: expected error for string accept")
+	}
+}
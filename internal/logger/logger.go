@@ -159,6 +159,11 @@ func isTerminal() bool {
 	return (fileInfo.Mode() & os.ModeCharDevice) != 0
 }
 
+// ToStderr переводит цветной вывод в stderr, когда stdout занят результатами (--batch)
+func ToStderr() {
+	color.Output = os.Stderr
+}
+
 func disableColors() {
 	color.NoColor = true
 }
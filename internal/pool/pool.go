@@ -0,0 +1,339 @@
+// Package pool выполняет задачи агента параллельно в изолированных контекстах браузера.
+// У каждого места пула свои вкладки, Extractor и Agent; упавший Chrome пересоздаётся.
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stannisl/ai-browser-assistant/internal/agent"
+	"github.com/stannisl/ai-browser-assistant/internal/browser"
+	"github.com/stannisl/ai-browser-assistant/internal/extractor"
+	"github.com/stannisl/ai-browser-assistant/internal/llm"
+	"github.com/stannisl/ai-browser-assistant/internal/logger"
+	"github.com/stannisl/ai-browser-assistant/internal/profiles"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// Isolation — как разделяются контексты браузера между местами пула
+type Isolation string
+
+const (
+	// IsolationIncognito — один процесс Chrome, каждая задача в новом инкогнито-контексте:
+	// задачи не видят cookies и хранилища друг друга, запуск контекста почти бесплатный
+	IsolationIncognito Isolation = "incognito"
+	// IsolationProfile — у каждого места свой Chrome с каталогом данных UserDataDir/worker-N:
+	// входы на сайты сохраняются между задачами этого места
+	IsolationProfile Isolation = "profile"
+)
+
+var (
+	ErrQueueFull    = errors.New("pool queue is full")
+	ErrQueueTimeout = errors.New("timed out waiting for a free browser in the pool")
+	ErrClosed       = errors.New("pool is closed")
+)
+
+// Config — настройки пула
+type Config struct {
+	// Size — сколько задач выполняется одновременно (0 — 1)
+	Size int
+	// MaxQueue — сколько задач может ждать свободного места; 0 — без ограничения
+	MaxQueue int
+	// QueueTimeout — сколько задача ждёт свободного места; 0 — пока не отменён контекст
+	QueueTimeout time.Duration
+	// Isolation — разделение контекстов (пусто — IsolationIncognito)
+	Isolation Isolation
+	// Browser — настройки браузера; при IsolationProfile UserDataDir — корень каталогов мест
+	Browser types.BrowserConfig
+	// Agent — настройки агентов; артефакты каждого места пишутся в ArtifactsDir/worker-N
+	Agent types.AgentConfig
+}
+
+// Stats — состояние пула
+type Stats struct {
+	Size   int
+	Busy   int
+	Queued int
+	// Recycled — сколько раз браузер пересоздавался после падения
+	Recycled int
+}
+
+// worker — браузер места пула с агентом
+type worker interface {
+	Run(ctx context.Context, task types.Task) (*types.RunResult, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+type slot struct {
+	id int
+	w  worker
+}
+
+// Pool раздаёт задачам места с изолированным браузером
+type Pool struct {
+	cfg      Config
+	llm      *llm.Client
+	profiles *profiles.Registry
+	log      *logger.Logger
+
+	slots chan *slot
+	done  chan struct{}
+	once  sync.Once
+
+	busy     atomic.Int32
+	queued   atomic.Int32
+	recycled atomic.Int32
+
+	// rootMu защищает общий браузер инкогнито-контекстов
+	rootMu sync.Mutex
+	root   *browser.Manager
+
+	newWorker func(ctx context.Context, id int) (worker, error)
+}
+
+// New создаёт пул. Браузеры запускаются при первой задаче, а не заранее.
+func New(cfg Config, llmClient *llm.Client, siteProfiles *profiles.Registry, log *logger.Logger) (*Pool, error) {
+	if cfg.Size <= 0 {
+		cfg.Size = 1
+	}
+	if cfg.Isolation == "" {
+		cfg.Isolation = IsolationIncognito
+	}
+
+	p := &Pool{
+		cfg:      cfg,
+		llm:      llmClient,
+		profiles: siteProfiles,
+		log:      log,
+		slots:    make(chan *slot, cfg.Size),
+		done:     make(chan struct{}),
+	}
+
+	switch cfg.Isolation {
+	case IsolationIncognito:
+		p.newWorker = p.newIncognitoWorker
+	case IsolationProfile:
+		// Один подключённый браузер нельзя разделить на несколько процессов Chrome
+		if cfg.Browser.RemoteURL != "" {
+			return nil, fmt.Errorf("isolation %q needs its own browsers: use %q with an attached browser", IsolationProfile, IsolationIncognito)
+		}
+		p.newWorker = p.newProfileWorker
+	default:
+		return nil, fmt.Errorf("unknown pool isolation %q", cfg.Isolation)
+	}
+
+	for i := 1; i <= cfg.Size; i++ {
+		p.slots <- &slot{id: i}
+	}
+	return p, nil
+}
+
+// Run выполняет задачу на свободном месте пула, дожидаясь его в очереди
+func (p *Pool) Run(ctx context.Context, task types.Task) (*types.RunResult, error) {
+	s, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.busy.Add(1)
+	defer func() {
+		p.busy.Add(-1)
+		p.slots <- s
+	}()
+
+	if s.w != nil {
+		if err := s.w.Ping(ctx); err != nil {
+			p.log.Warn("Pool browser is unhealthy, recycling", "worker", s.id, "error", err)
+			p.discard(s)
+			p.recycled.Add(1)
+		}
+	}
+	if s.w == nil {
+		w, err := p.newWorker(ctx, s.id)
+		if err != nil {
+			return nil, fmt.Errorf("start pool worker %d: %w", s.id, err)
+		}
+		s.w = w
+	}
+
+	result, err := s.w.Run(ctx, task)
+
+	switch {
+	case p.cfg.Isolation == IsolationIncognito:
+		// Следующая задача получит чистый контекст
+		p.discard(s)
+	case err != nil && s.w.Ping(ctx) != nil:
+		p.log.Warn("Pool browser crashed during task, recycling", "worker", s.id)
+		p.discard(s)
+		p.recycled.Add(1)
+	}
+	return result, err
+}
+
+// Stats возвращает текущее состояние пула
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Size:     p.cfg.Size,
+		Busy:     int(p.busy.Load()),
+		Queued:   int(p.queued.Load()),
+		Recycled: int(p.recycled.Load()),
+	}
+}
+
+// Close закрывает браузеры пула. Новые задачи получают ErrClosed, выполняемые
+// задачи дорабатывают: Close ждёт, пока освободятся все места.
+func (p *Pool) Close() error {
+	p.once.Do(func() { close(p.done) })
+
+	for i := 0; i < p.cfg.Size; i++ {
+		s := <-p.slots
+		p.discard(s)
+	}
+
+	p.rootMu.Lock()
+	defer p.rootMu.Unlock()
+	if p.root != nil {
+		_ = p.root.Close()
+		p.root = nil
+	}
+	return nil
+}
+
+func (p *Pool) acquire(ctx context.Context) (*slot, error) {
+	select {
+	case <-p.done:
+		return nil, ErrClosed
+	default:
+	}
+
+	select {
+	case s := <-p.slots:
+		return s, nil
+	default:
+	}
+
+	if n := int(p.queued.Add(1)); p.cfg.MaxQueue > 0 && n > p.cfg.MaxQueue {
+		p.queued.Add(-1)
+		return nil, ErrQueueFull
+	}
+	defer p.queued.Add(-1)
+
+	var timeout <-chan time.Time
+	if p.cfg.QueueTimeout > 0 {
+		timer := time.NewTimer(p.cfg.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case s := <-p.slots:
+		return s, nil
+	case <-p.done:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		return nil, ErrQueueTimeout
+	}
+}
+
+func (p *Pool) discard(s *slot) {
+	if s.w == nil {
+		return
+	}
+	if err := s.w.Close(); err != nil {
+		p.log.Warn("Failed to close pool browser", "worker", s.id, "error", err)
+	}
+	s.w = nil
+}
+
+// rootBrowser возвращает общий браузер инкогнито-контекстов, перезапуская его после падения
+func (p *Pool) rootBrowser(ctx context.Context) (*browser.Manager, error) {
+	p.rootMu.Lock()
+	defer p.rootMu.Unlock()
+
+	if p.root != nil {
+		err := p.root.Ping(ctx)
+		if err == nil {
+			return p.root, nil
+		}
+		p.log.Warn("Pool browser is unhealthy, restarting", "error", err)
+		_ = p.root.Close()
+		p.root = nil
+		p.recycled.Add(1)
+	}
+
+	cfg := p.cfg.Browser
+	root := browser.NewManager(&cfg, p.log)
+	if err := root.Launch(ctx); err != nil {
+		return nil, err
+	}
+	p.root = root
+	return root, nil
+}
+
+func (p *Pool) newIncognitoWorker(ctx context.Context, id int) (worker, error) {
+	root, err := p.rootBrowser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mgr, err := root.NewIncognito()
+	if err != nil {
+		return nil, err
+	}
+	return p.newAgentWorker(mgr, id), nil
+}
+
+func (p *Pool) newProfileWorker(ctx context.Context, id int) (worker, error) {
+	cfg := p.cfg.Browser
+	if cfg.UserDataDir != "" {
+		cfg.UserDataDir = filepath.Join(cfg.UserDataDir, workerDir(id))
+	}
+
+	mgr := browser.NewManager(&cfg, p.log)
+	if err := mgr.Launch(ctx); err != nil {
+		// Запуск мог упасть после старта Chrome: освобождаем процесс и блокировку
+		_ = mgr.Close()
+		return nil, err
+	}
+	return p.newAgentWorker(mgr, id), nil
+}
+
+// agentWorker — браузер места с собственными Extractor и Agent
+type agentWorker struct {
+	mgr   *browser.Manager
+	agent *agent.Agent
+}
+
+func (p *Pool) newAgentWorker(mgr *browser.Manager, id int) *agentWorker {
+	ext := extractor.New(mgr.GetPage(), p.log)
+	ext.SetProfiles(p.profiles)
+
+	cfg := p.cfg.Agent
+	if cfg.ArtifactsDir != "" {
+		// Задачи разных мест стартуют в одну секунду — каталоги артефактов не должны совпасть
+		cfg.ArtifactsDir = filepath.Join(cfg.ArtifactsDir, workerDir(id))
+	}
+	return &agentWorker{mgr: mgr, agent: agent.New(mgr, ext, p.llm, p.log, &cfg)}
+}
+
+func (w *agentWorker) Run(ctx context.Context, task types.Task) (*types.RunResult, error) {
+	return w.agent.Run(ctx, task)
+}
+
+func (w *agentWorker) Ping(ctx context.Context) error {
+	return w.mgr.Ping(ctx)
+}
+
+func (w *agentWorker) Close() error {
+	return w.mgr.Close()
+}
+
+func workerDir(id int) string {
+	return fmt.Sprintf("worker-%d", id)
+}
@@ -0,0 +1,296 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stannisl/ai-browser-assistant/internal/logger"
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+type fakeWorker struct {
+	run     func(ctx context.Context) error
+	healthy atomic.Bool
+	closed  atomic.Bool
+}
+
+func (w *fakeWorker) Run(ctx context.Context, task types.Task) (*types.RunResult, error) {
+	if w.run != nil {
+		if err := w.run(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &types.RunResult{Message: task.Prompt, Success: true}, nil
+}
+
+func (w *fakeWorker) Ping(ctx context.Context) error {
+	if !w.healthy.Load() {
+		return errors.New("browser crashed")
+	}
+	return nil
+}
+
+func (w *fakeWorker) Close() error {
+	w.closed.Store(true)
+	return nil
+}
+
+// newTestPool создаёт пул с поддельными браузерами и возвращает созданные воркеры
+func newTestPool(t *testing.T, cfg Config, run func(ctx context.Context) error) (*Pool, func() []*fakeWorker) {
+	t.Helper()
+	log, err := logger.New(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := New(cfg, nil, nil, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var workers []*fakeWorker
+	p.newWorker = func(ctx context.Context, id int) (worker, error) {
+		w := &fakeWorker{run: run}
+		w.healthy.Store(true)
+		mu.Lock()
+		workers = append(workers, w)
+		mu.Unlock()
+		return w, nil
+	}
+	return p, func() []*fakeWorker {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*fakeWorker(nil), workers...)
+	}
+}
+
+func TestPool_LimitsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	p, _ := newTestPool(t, Config{Size: 3}, func(ctx context.Context) error {
+		n := running.Add(1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	})
+	defer p.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Run(context.Background(), types.Task{Prompt: "lookup"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got != 3 {
+		t.Errorf("peak concurrency = %d, want 3", got)
+	}
+	if s := p.Stats(); s.Busy != 0 || s.Queued != 0 {
+		t.Errorf("unexpected stats after run: %+v", s)
+	}
+}
+
+func TestPool_Queue(t *testing.T) {
+	release := make(chan struct{})
+	p, _ := newTestPool(t, Config{Size: 1, MaxQueue: 1, QueueTimeout: 50 * time.Millisecond}, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	defer p.Close()
+
+	// Первая задача занимает единственное место
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Run(context.Background(), types.Task{})
+		done <- err
+	}()
+	waitFor(t, func() bool { return p.Stats().Busy == 1 })
+
+	// Вторая ждёт в очереди дольше QueueTimeout
+	queued := make(chan error, 1)
+	go func() {
+		_, err := p.Run(context.Background(), types.Task{})
+		queued <- err
+	}()
+	waitFor(t, func() bool { return p.Stats().Queued == 1 })
+
+	// Третьей места в очереди нет
+	if _, err := p.Run(context.Background(), types.Task{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+	if err := <-queued; !errors.Is(err, ErrQueueTimeout) {
+		t.Errorf("expected ErrQueueTimeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Run(ctx, types.Task{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPool_IncognitoFreshWorkerPerTask(t *testing.T) {
+	p, workers := newTestPool(t, Config{Size: 1}, nil)
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := p.Run(context.Background(), types.Task{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ws := workers()
+	if len(ws) != 3 {
+		t.Fatalf("expected a worker per task, got %d", len(ws))
+	}
+	for i, w := range ws {
+		if !w.closed.Load() {
+			t.Errorf("worker %d should be closed after its task", i)
+		}
+	}
+}
+
+func TestPool_ProfileRecyclesCrashedBrowser(t *testing.T) {
+	crash := errors.New("target closed")
+	var fail atomic.Bool
+	p, workers := newTestPool(t, Config{Size: 1, Isolation: IsolationProfile}, func(ctx context.Context) error {
+		if fail.Load() {
+			return crash
+		}
+		return nil
+	})
+	defer p.Close()
+
+	run := func() error {
+		_, err := p.Run(context.Background(), types.Task{})
+		return err
+	}
+
+	// Профильный браузер переживает задачи
+	for i := 0; i < 2; i++ {
+		if err := run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(workers()); n != 1 {
+		t.Fatalf("profile worker should be reused, got %d workers", n)
+	}
+
+	// Браузер упал между задачами — проверка перед задачей заменяет его
+	workers()[0].healthy.Store(false)
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	ws := workers()
+	if len(ws) != 2 || !ws[0].closed.Load() {
+		t.Fatalf("crashed worker should be replaced, got %d workers", len(ws))
+	}
+
+	// Браузер упал во время задачи — ошибка задачи возвращается, браузер заменяется
+	fail.Store(true)
+	ws[1].healthy.Store(false)
+	if err := run(); !errors.Is(err, crash) {
+		t.Fatalf("expected task error, got %v", err)
+	}
+	if !ws[1].closed.Load() {
+		t.Error("worker crashed during task should be closed")
+	}
+	if got := p.Stats().Recycled; got != 2 {
+		t.Errorf("Recycled = %d, want 2", got)
+	}
+}
+
+func TestPool_Close(t *testing.T) {
+	release := make(chan struct{})
+	p, workers := newTestPool(t, Config{Size: 2, Isolation: IsolationProfile}, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Run(context.Background(), types.Task{})
+		done <- err
+	}()
+	waitFor(t, func() bool { return p.Stats().Busy == 1 })
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+
+	// Close ждёт выполняемую задачу, но новые задачи уже не принимаются
+	<-p.done
+	if _, err := p.Run(context.Background(), types.Task{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a task was running")
+	default:
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	<-closed
+	if !workers()[0].closed.Load() {
+		t.Error("worker browser should be closed")
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	log, err := logger.New(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(Config{Isolation: "containers"}, nil, nil, log); err == nil {
+		t.Error("expected error for unknown isolation")
+	}
+	cfg := Config{Isolation: IsolationProfile, Browser: types.BrowserConfig{RemoteURL: "9222"}}
+	if _, err := New(cfg, nil, nil, log); err == nil {
+		t.Error("profile isolation with an attached browser should be rejected")
+	}
+
+	p, err := New(Config{}, nil, nil, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Stats(); s.Size != 1 || p.cfg.Isolation != IsolationIncognito {
+		t.Errorf("unexpected defaults: %+v, %s", s, p.cfg.Isolation)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,226 @@
+package profiles
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed builtin/*.yaml
+var builtinFS embed.FS
+
+// Profile описывает особенности конкретного сайта: какие элементы дополнительно
+// считать интерактивными, где искать строки контента и что подсказать модели.
+// Профили задаются в YAML или прямо в Go и выбираются по URL страницы.
+type Profile struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Match — шаблоны URL: "mail.google.com", "*.mail.ru", "jira.corp.local/browse/*".
+	// Шаблон без пути сравнивается только с хостом, * совпадает с любой подстрокой.
+	Match    []string     `yaml:"match"`
+	Elements ElementRules `yaml:"elements"`
+	Content  ContentRules `yaml:"content"`
+	// Hints — подсказки и сценарии работы с сайтом, добавляются в контекст модели
+	Hints string `yaml:"hints"`
+
+	patterns []*regexp.Regexp
+}
+
+// ElementRules дополняет поиск интерактивных элементов
+type ElementRules struct {
+	// Selectors — дополнительные CSS-селекторы интерактивных элементов
+	Selectors []string `yaml:"selectors"`
+	// Checkboxes — элементы, которые надо считать чекбоксами (кастомные чекбоксы выбора строк)
+	Checkboxes []string `yaml:"checkboxes"`
+	// Buttons — тексты, по которым элемент показывается модели как кнопка
+	Buttons []string `yaml:"buttons"`
+}
+
+// ContentRules описывает, где на странице лежит контент
+type ContentRules struct {
+	// Rows — селекторы строк списков (письма, задачи, результаты поиска)
+	Rows []string `yaml:"rows"`
+	// RowLabels — селектор заголовка внутри строки (тема письма), им подписываются чекбоксы
+	RowLabels []string `yaml:"row_labels"`
+	// Blocks — селекторы открытого контента (тело письма, карточка задачи)
+	Blocks []string `yaml:"blocks"`
+}
+
+// Registry хранит профили в порядке регистрации
+type Registry struct {
+	profiles []*Profile
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Builtin возвращает реестр со встроенными профилями
+func Builtin() (*Registry, error) {
+	r := NewRegistry()
+
+	entries, err := fs.ReadDir(builtinFS, "builtin")
+	if err != nil {
+		return nil, fmt.Errorf("read builtin profiles: %w", err)
+	}
+	for _, entry := range entries {
+		data, err := builtinFS.ReadFile("builtin/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read builtin profile %s: %w", entry.Name(), err)
+		}
+		p, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("builtin profile %s: %w", entry.Name(), err)
+		}
+		if err := r.Register(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Parse разбирает YAML-профиль
+func Parse(data []byte) (*Profile, error) {
+	var p Profile
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse profile: %w", err)
+	}
+	return &p, nil
+}
+
+// Register добавляет профиль. Профиль с тем же именем заменяет ранее
+// зарегистрированный, так пользователь может переопределить встроенный.
+func (r *Registry) Register(p *Profile) error {
+	if p.Name == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if len(p.Match) == 0 {
+		return fmt.Errorf("profile %s: at least one match pattern is required", p.Name)
+	}
+
+	p.patterns = p.patterns[:0]
+	for _, m := range p.Match {
+		re, err := compilePattern(m)
+		if err != nil {
+			return fmt.Errorf("profile %s: invalid match pattern %q: %w", p.Name, m, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+
+	for i, existing := range r.profiles {
+		if existing.Name == p.Name {
+			r.profiles[i] = p
+			return nil
+		}
+	}
+	r.profiles = append(r.profiles, p)
+	return nil
+}
+
+// LoadDir регистрирует все *.yaml и *.yml профили из каталога.
+// Отсутствующий каталог не считается ошибкой.
+func (r *Registry) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read profiles dir: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read profile %s: %w", path, err)
+		}
+		p, err := Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := r.Register(p); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Match возвращает профили, подходящие к URL, в порядке регистрации
+func (r *Registry) Match(rawURL string) []*Profile {
+	if r == nil {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	full := host + u.EscapedPath()
+
+	var matched []*Profile
+	for _, p := range r.profiles {
+		if p.matches(host, full) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// Profiles возвращает все зарегистрированные профили
+func (r *Registry) Profiles() []*Profile {
+	if r == nil {
+		return nil
+	}
+	return r.profiles
+}
+
+func (p *Profile) matches(host, full string) bool {
+	for i, re := range p.patterns {
+		target := full
+		if !strings.Contains(p.Match[i], "/") {
+			target = host
+		}
+		if re.MatchString(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePattern переводит шаблон с * в регулярное выражение, схема URL отбрасывается
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if i := strings.Index(pattern, "://"); i >= 0 {
+		pattern = pattern[i+3:]
+	}
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
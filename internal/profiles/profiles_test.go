@@ -0,0 +1,162 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	r, err := Builtin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{url: "https://mail.google.com/mail/u/0/#inbox", want: []string{"gmail"}},
+		{url: "https://mail.yandex.ru/?uid=1#inbox", want: []string{"yandex-mail"}},
+		{url: "https://mail.yandex.com/", want: []string{"yandex-mail"}},
+		{url: "https://e.mail.ru/inbox/", want: []string{"mail-ru"}},
+		{url: "https://mail.ru/", want: []string{"mail-ru"}},
+		{url: "https://hh.ru/search/vacancy", want: nil},
+		{url: "about:blank", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			var got []string
+			for _, p := range r.Match(tt.url) {
+				got = append(got, p.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch_Patterns(t *testing.T) {
+	r := NewRegistry()
+	err := r.Register(&Profile{
+		Name:  "jira",
+		Match: []string{"https://jira.corp.local/browse/*", "*.tracker.local"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "https://jira.corp.local/browse/PRJ-1", want: true},
+		{url: "http://jira.corp.local/browse/", want: true},
+		{url: "https://jira.corp.local/dashboard", want: false},
+		{url: "https://team.tracker.local/issues", want: true},
+		{url: "https://tracker.local/", want: false},
+		{url: "https://TEAM.Tracker.local/", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := len(r.Match(tt.url)) == 1; got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegister_Invalid(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Register(&Profile{Match: []string{"example.com"}}); err == nil {
+		t.Error("expected error for profile without name")
+	}
+	if err := r.Register(&Profile{Name: "empty"}); err == nil {
+		t.Error("expected error for profile without match patterns")
+	}
+	if err := r.Register(&Profile{Name: "blank", Match: []string{" "}}); err == nil {
+		t.Error("expected error for blank pattern")
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	crm := `name: crm
+match:
+  - crm.corp.local
+elements:
+  selectors:
+    - .crm-action
+  buttons:
+    - archive
+content:
+  rows:
+    - .crm-deal-row
+hints: Deals are listed on /deals.
+`
+	override := `name: gmail
+match:
+  - mail.google.com
+hints: Custom Gmail hints.
+`
+	if err := os.WriteFile(filepath.Join(dir, "crm.yaml"), []byte(crm), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gmail.yml"), []byte(override), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a profile"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Builtin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	builtinCount := len(r.Profiles())
+
+	if err := r.LoadDir(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := len(r.Profiles()); got != builtinCount+1 {
+		t.Errorf("got %d profiles, want %d", got, builtinCount+1)
+	}
+
+	matched := r.Match("https://crm.corp.local/deals")
+	if len(matched) != 1 {
+		t.Fatalf("expected crm profile to match, got %d profiles", len(matched))
+	}
+	p := matched[0]
+	if !reflect.DeepEqual(p.Elements.Selectors, []string{".crm-action"}) ||
+		!reflect.DeepEqual(p.Elements.Buttons, []string{"archive"}) ||
+		!reflect.DeepEqual(p.Content.Rows, []string{".crm-deal-row"}) {
+		t.Errorf("unexpected rules: %+v %+v", p.Elements, p.Content)
+	}
+
+	gmail := r.Match("https://mail.google.com/")
+	if len(gmail) != 1 || gmail[0].Hints != "Custom Gmail hints." {
+		t.Errorf("expected user profile to override builtin gmail, got %+v", gmail)
+	}
+
+	if err := r.LoadDir(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("missing dir should not be an error, got %v", err)
+	}
+}
+
+func TestLoadDir_InvalidProfile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("name: [unclosed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewRegistry().LoadDir(dir); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}
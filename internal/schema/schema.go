@@ -0,0 +1,297 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ValidationError описывает одно нарушение схемы. Path — путь к значению в формате $.items[0].title
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate проверяет JSON-документ по JSON Schema. Поддерживается подмножество, достаточное
+// для описания результата задачи: type, properties, required, additionalProperties, items,
+// enum, const, anyOf, minItems/maxItems, minLength/maxLength, minimum/maximum, pattern.
+// Ошибка возвращается, только если схема или документ не являются корректным JSON.
+func Validate(schema, data json.RawMessage) ([]ValidationError, error) {
+	var s map[string]interface{}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var errs []ValidationError
+	validate(s, v, "$", &errs)
+	return errs, nil
+}
+
+// FormatErrors собирает ошибки валидации в одну строку для модели
+func FormatErrors(errs []ValidationError) string {
+	parts := make([]string, len(errs))
+	for i, e := range errs {
+		parts[i] = e.Error()
+	}
+	return strings.Join(parts, "; ")
+}
+
+func validate(s map[string]interface{}, v interface{}, path string, errs *[]ValidationError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, v) {
+		fail("expected %s, got %s", typeString(t), jsonType(v))
+		return
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value must be one of %v", enum)
+		}
+	}
+
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, v) {
+		fail("value must be %v", c)
+	}
+
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			subSchema, ok := sub.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var subErrs []ValidationError
+			validate(subSchema, v, path, &subErrs)
+			if len(subErrs) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("value does not match any of the allowed schemas")
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		validateObject(s, val, path, errs)
+	case []interface{}:
+		if n, ok := number(s["minItems"]); ok && float64(len(val)) < n {
+			fail("expected at least %v items, got %d", n, len(val))
+		}
+		if n, ok := number(s["maxItems"]); ok && float64(len(val)) > n {
+			fail("expected at most %v items, got %d", n, len(val))
+		}
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				validate(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		length := float64(len([]rune(val)))
+		if n, ok := number(s["minLength"]); ok && length < n {
+			fail("expected at least %v characters", n)
+		}
+		if n, ok := number(s["maxLength"]); ok && length > n {
+			fail("expected at most %v characters", n)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err == nil && !re.MatchString(val) {
+				fail("value does not match pattern %q", pattern)
+			}
+		}
+	case float64:
+		if n, ok := number(s["minimum"]); ok && val < n {
+			fail("value must be >= %v", n)
+		}
+		if n, ok := number(s["maximum"]); ok && val > n {
+			fail("value must be <= %v", n)
+		}
+	}
+}
+
+func validateObject(s map[string]interface{}, obj map[string]interface{}, path string, errs *[]ValidationError) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", name)})
+			}
+		}
+	}
+
+	props, _ := s["properties"].(map[string]interface{})
+
+	// Обходим ключи в стабильном порядке, чтобы ошибки не менялись от запуска к запуску
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		childPath := path + "." + k
+		if propSchema, ok := props[k].(map[string]interface{}); ok {
+			validate(propSchema, obj[k], childPath, errs)
+			continue
+		}
+
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, ValidationError{Path: childPath, Message: "additional property is not allowed"})
+			}
+		case map[string]interface{}:
+			validate(additional, obj[k], childPath, errs)
+		}
+	}
+}
+
+func matchesType(t interface{}, v interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesSingleType(tt, v)
+	case []interface{}:
+		for _, item := range tt {
+			if name, ok := item.(string); ok && matchesSingleType(name, v) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func matchesSingleType(t string, v interface{}) bool {
+	switch t {
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	default:
+		return jsonType(v) == t
+	}
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func typeString(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+// PropertyOrder возвращает имена свойств записи в порядке объявления в схеме.
+// Для схемы массива берутся свойства items, для объекта с единственным
+// свойством-массивом — свойства его элементов.
+func PropertyOrder(schema json.RawMessage) []string {
+	var s orderedSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil
+	}
+
+	if s.Items != nil {
+		return s.Items.Properties.keys
+	}
+	if len(s.Properties.keys) == 1 {
+		if inner := s.Properties.schemas[s.Properties.keys[0]]; inner != nil && inner.Items != nil {
+			return inner.Items.Properties.keys
+		}
+	}
+	return s.Properties.keys
+}
+
+type orderedSchema struct {
+	Properties orderedProperties `json:"properties"`
+	Items      *orderedSchema    `json:"items"`
+}
+
+// orderedProperties сохраняет порядок ключей properties, который теряется при разборе в map
+type orderedProperties struct {
+	keys    []string
+	schemas map[string]*orderedSchema
+}
+
+func (p *orderedProperties) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("properties must be an object")
+	}
+
+	p.schemas = map[string]*orderedSchema{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+
+		var sub orderedSchema
+		if err := dec.Decode(&sub); err != nil {
+			return err
+		}
+		p.keys = append(p.keys, key)
+		p.schemas[key] = &sub
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const vacanciesSchema = `{
+	"type": "array",
+	"minItems": 1,
+	"items": {
+		"type": "object",
+		"required": ["title", "company", "url"],
+		"additionalProperties": false,
+		"properties": {
+			"title": {"type": "string", "minLength": 1},
+			"company": {"type": "string"},
+			"salary": {"type": ["integer", "null"], "minimum": 0},
+			"url": {"type": "string", "pattern": "^https?://"}
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantPaths []string
+	}{
+		{
+			name:      "valid",
+			data:      `[{"title": "Go developer", "company": "Acme", "salary": 300000, "url": "https://example.com/1"}]`,
+			wantPaths: nil,
+		},
+		{
+			name:      "null salary allowed",
+			data:      `[{"title": "Go developer", "company": "Acme", "salary": null, "url": "https://example.com/1"}]`,
+			wantPaths: nil,
+		},
+		{
+			name:      "not an array",
+			data:      `{"title": "Go developer"}`,
+			wantPaths: []string{"$"},
+		},
+		{
+			name:      "empty array",
+			data:      `[]`,
+			wantPaths: []string{"$"},
+		},
+		{
+			name:      "missing required and wrong types",
+			data:      `[{"title": "", "salary": 1.5, "url": "example.com", "extra": 1}]`,
+			wantPaths: []string{"$[0]", "$[0].extra", "$[0].salary", "$[0].title", "$[0].url"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := Validate(json.RawMessage(vacanciesSchema), json.RawMessage(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var paths []string
+			for _, e := range errs {
+				paths = append(paths, e.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("got error paths %v, want %v (errors: %s)", paths, tt.wantPaths, FormatErrors(errs))
+			}
+		})
+	}
+}
+
+func TestValidate_EnumAndAnyOf(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"status": {"enum": ["new", "read"]},
+			"id": {"anyOf": [{"type": "string"}, {"type": "integer"}]}
+		}
+	}`)
+
+	errs, err := Validate(schema, json.RawMessage(`{"status": "new", "id": 5}`))
+	if err != nil || len(errs) != 0 {
+		t.Errorf("expected valid document, got %v, %v", errs, err)
+	}
+
+	errs, err = Validate(schema, json.RawMessage(`{"status": "deleted", "id": true}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected 2 errors, got %d: %s", len(errs), FormatErrors(errs))
+	}
+}
+
+func TestValidate_InvalidJSON(t *testing.T) {
+	if _, err := Validate(json.RawMessage(`{`), json.RawMessage(`{}`)); err == nil {
+		t.Error("expected error for invalid schema")
+	}
+	if _, err := Validate(json.RawMessage(`{}`), json.RawMessage(`[1,`)); err == nil {
+		t.Error("expected error for invalid document")
+	}
+}
+
+func TestPropertyOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   []string
+	}{
+		{
+			name:   "array of objects",
+			schema: vacanciesSchema,
+			want:   []string{"title", "company", "salary", "url"},
+		},
+		{
+			name:   "object wrapping an array",
+			schema: `{"type": "object", "properties": {"emails": {"type": "array", "items": {"properties": {"from": {}, "subject": {}, "date": {}}}}}}`,
+			want:   []string{"from", "subject", "date"},
+		},
+		{
+			name:   "plain object",
+			schema: `{"type": "object", "properties": {"b": {}, "a": {}}}`,
+			want:   []string{"b", "a"},
+		},
+		{
+			name:   "invalid schema",
+			schema: `{`,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PropertyOrder(json.RawMessage(tt.schema)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Package session сохраняет и загружает переносимое состояние входа на сайты
+// (cookies, localStorage, sessionStorage) с необязательным шифрованием.
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+// FormatVersion — версия формата файла сессии
+const FormatVersion = 1
+
+const (
+	cipherName = "aes-256-gcm"
+	kdfName    = "pbkdf2-sha256"
+	// kdfIterations — число итераций PBKDF2 для новых файлов (рекомендация OWASP для SHA-256)
+	kdfIterations = 600_000
+	// maxKDFIterations ограничивает итерации из файла, чтобы чужой файл не подвесил импорт
+	maxKDFIterations = 10_000_000
+	saltSize         = 16
+	keySize          = 32
+)
+
+var (
+	ErrPassphraseRequired = errors.New("session file is encrypted: passphrase required")
+	ErrWrongPassphrase    = errors.New("cannot decrypt session file: wrong passphrase or corrupted file")
+)
+
+// envelope — зашифрованный файл сессии: параметры шифрования и шифротекст JSON состояния
+type envelope struct {
+	Version    int         `json:"version"`
+	Encryption *encryption `json:"encryption"`
+	Data       []byte      `json:"data"`
+}
+
+type encryption struct {
+	Cipher     string `json:"cipher"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+}
+
+// Encode сериализует состояние в JSON. С непустой парольной фразой результат шифруется
+// AES-256-GCM ключом, полученным из фразы через PBKDF2.
+func Encode(state *types.SessionState, passphrase string) ([]byte, error) {
+	state.Version = FormatVersion
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal session: %w", err)
+	}
+	if passphrase == "" {
+		return append(data, '\n'), nil
+	}
+
+	enc := &encryption{
+		Cipher:     cipherName,
+		KDF:        kdfName,
+		Iterations: kdfIterations,
+		Salt:       make([]byte, saltSize),
+	}
+	if _, err := rand.Read(enc.Salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+
+	aead, err := newAEAD(passphrase, enc)
+	if err != nil {
+		return nil, err
+	}
+	enc.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(enc.Nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	out, err := json.MarshalIndent(envelope{
+		Version:    FormatVersion,
+		Encryption: enc,
+		Data:       aead.Seal(nil, enc.Nonce, data, nil),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal encrypted session: %w", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// Decode читает файл сессии, открытый или зашифрованный
+func Decode(data []byte, passphrase string) (*types.SessionState, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("parse session file: %w", err)
+	}
+
+	if env.Encryption != nil {
+		if passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		enc := env.Encryption
+		if enc.Cipher != cipherName || enc.KDF != kdfName {
+			return nil, fmt.Errorf("unsupported session encryption %s/%s", enc.Cipher, enc.KDF)
+		}
+		if enc.Iterations < 1 || enc.Iterations > maxKDFIterations {
+			return nil, fmt.Errorf("invalid key derivation iterations: %d", enc.Iterations)
+		}
+
+		aead, err := newAEAD(passphrase, enc)
+		if err != nil {
+			return nil, err
+		}
+		if len(enc.Nonce) != aead.NonceSize() {
+			return nil, ErrWrongPassphrase
+		}
+		data, err = aead.Open(nil, enc.Nonce, env.Data, nil)
+		if err != nil {
+			return nil, ErrWrongPassphrase
+		}
+	}
+
+	var state types.SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse session: %w", err)
+	}
+	if state.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported session format version %d", state.Version)
+	}
+	return &state, nil
+}
+
+func newAEAD(passphrase string, enc *encryption) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, enc.Salt, enc.Iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// MatchDomain проверяет, что хост или домен cookie (".mail.ru") относится к одному из доменов:
+// совпадает с ним или является его поддоменом. Пустой список подходит для любого хоста.
+func MatchDomain(host string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+
+	host = strings.ToLower(strings.TrimPrefix(host, "."))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "."))
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchOrigin проверяет, что хост origin (https://e.mail.ru) относится к одному из доменов
+func MatchOrigin(origin string, domains []string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return MatchDomain(u.Hostname(), domains)
+}
+
+// Origins возвращает origin, хранилища которых нужно сохранить: сами домены и их www,
+// а также хосты cookie. Хранилища привязаны к origin, а браузер не даёт их список.
+func Origins(domains []string, cookies []types.SessionCookie) []string {
+	seen := map[string]bool{}
+	add := func(host string) {
+		host = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(host), "."))
+		if host == "" {
+			return
+		}
+		seen["https://"+host] = true
+	}
+
+	for _, d := range domains {
+		add(d)
+		add("www." + strings.TrimPrefix(d, "."))
+	}
+	for _, c := range cookies {
+		if MatchDomain(c.Domain, domains) {
+			add(c.Domain)
+		}
+	}
+
+	origins := make([]string, 0, len(seen))
+	for o := range seen {
+		origins = append(origins, o)
+	}
+	sort.Strings(origins)
+	return origins
+}
@@ -0,0 +1,145 @@
+package session
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stannisl/ai-browser-assistant/internal/types"
+)
+
+func testState() *types.SessionState {
+	return &types.SessionState{
+		ExportedAt: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		Domains:    []string{"mail.ru"},
+		Cookies: []types.SessionCookie{
+			{Name: "Mpop", Value: "secret-token", Domain: ".mail.ru", Path: "/", Expires: 1900000000, HTTPOnly: true, Secure: true, SameSite: "Lax"},
+		},
+		Origins: []types.OriginStorage{
+			{Origin: "https://e.mail.ru", LocalStorage: map[string]string{"theme": "dark"}, SessionStorage: map[string]string{"tab": "inbox"}},
+		},
+	}
+}
+
+func TestEncodeDecode_Plain(t *testing.T) {
+	data, err := Encode(testState(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("secret-token")) {
+		t.Error("plain session should be readable JSON")
+	}
+
+	got, err := Decode(data, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := testState()
+	want.Version = FormatVersion
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// Парольная фраза для открытого файла не нужна, но и не мешает
+	if _, err := Decode(data, "unused"); err != nil {
+		t.Errorf("plain file with passphrase: %v", err)
+	}
+}
+
+func TestEncodeDecode_Encrypted(t *testing.T) {
+	data, err := Encode(testState(), "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret-token")) || bytes.Contains(data, []byte("e.mail.ru")) {
+		t.Error("encrypted session must not contain plain values")
+	}
+
+	got, err := Decode(data, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Cookies) != 1 || got.Cookies[0].Value != "secret-token" || got.Origins[0].LocalStorage["theme"] != "dark" {
+		t.Errorf("unexpected decrypted state: %+v", got)
+	}
+
+	if _, err := Decode(data, ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("expected ErrPassphraseRequired, got %v", err)
+	}
+	if _, err := Decode(data, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("expected ErrWrongPassphrase, got %v", err)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"not json", "cookies", "parse session file"},
+		{"unknown version", `{"version": 2, "cookies": []}`, "unsupported session format version 2"},
+		{"unknown cipher", `{"version": 1, "encryption": {"cipher": "rot13", "kdf": "pbkdf2-sha256", "iterations": 1}, "data": ""}`, "unsupported session encryption"},
+		{"too many iterations", `{"version": 1, "encryption": {"cipher": "aes-256-gcm", "kdf": "pbkdf2-sha256", "iterations": 1000000000}, "data": ""}`, "invalid key derivation iterations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode([]byte(tt.data), "pass")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	domains := []string{"mail.ru", ".Example.com"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"mail.ru", true},
+		{".mail.ru", true},
+		{"e.mail.ru", true},
+		{"gmail.ru", false},
+		{"mail.ru.evil.com", false},
+		{"www.example.com", true},
+		{"example.org", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchDomain(tt.host, domains); got != tt.want {
+			t.Errorf("MatchDomain(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+	if !MatchDomain("anything.org", nil) {
+		t.Error("empty domain list should match any host")
+	}
+
+	if !MatchOrigin("https://e.mail.ru:8443", domains) || MatchOrigin("about:blank", domains) {
+		t.Error("MatchOrigin should compare the origin host")
+	}
+}
+
+func TestOrigins(t *testing.T) {
+	cookies := []types.SessionCookie{
+		{Domain: ".mail.ru"},
+		{Domain: "e.mail.ru"},
+		{Domain: "ads.other.com"},
+	}
+
+	got := Origins([]string{"mail.ru"}, cookies)
+	want := []string{"https://e.mail.ru", "https://mail.ru", "https://www.mail.ru"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Origins = %v, want %v", got, want)
+	}
+
+	all := Origins(nil, cookies)
+	if len(all) != 3 || all[0] != "https://ads.other.com" {
+		t.Errorf("without domains all cookie hosts expected, got %v", all)
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type ToolCall struct {
 	ID          string
@@ -14,6 +17,24 @@ type ToolCall struct {
 	ToolCalls   []ToolCall
 }
 
+// Task описывает задачу агенту. OutputSchema — необязательная JSON Schema, которой
+// должен соответствовать результат (RunResult.Data).
+type Task struct {
+	Prompt       string
+	OutputSchema json.RawMessage
+	// Emulation — устройство, язык и регион на время задачи поверх BrowserConfig.Emulation
+	Emulation *Emulation
+}
+
+// RunResult — итог выполнения задачи: сообщение из report и структурированные данные,
+// если они были переданы.
+type RunResult struct {
+	Message string
+	Success bool
+	Data    json.RawMessage
+	Steps   int
+}
+
 type AgentConfig struct {
 	MaxRetries           int
 	Timeout              time.Duration
@@ -24,6 +45,14 @@ type AgentConfig struct {
 	SummaryEnabled       bool
 	SummarizeEvery       time.Duration
 	MaxSteps             int
+	// SchemaAttempts — сколько попыток у модели получить данные, подходящие под схему
+	// (extract_structured, report); 0 — значение по умолчанию
+	SchemaAttempts int
+	// NonInteractive — спросить пользователя нельзя (--batch): ask_user не ждёт ответа,
+	// а действия, требующие подтверждения, отклоняются
+	NonInteractive bool
+	// ArtifactsDir — каталог, в котором для каждой задачи создаётся папка с артефактами (скриншоты)
+	ArtifactsDir string
 }
 
 type LLMConfig struct {
@@ -34,6 +63,11 @@ type LLMConfig struct {
 	Temperature    float64
 	MaxRetries     int
 	RequestTimeout time.Duration
+	// Vision — модель принимает изображения во входных сообщениях
+	Vision bool
+	// Proxy — прокси запросов к модели, независимый от прокси браузера (http://, socks5://).
+	// Пусто — по переменным HTTPS_PROXY/NO_PROXY, "direct" — напрямую, без прокси.
+	Proxy string
 }
 
 type ToolDefinition struct {
@@ -408,3 +408,36 @@ func TestLinkElement_EmptyHref(t *testing.T) {
 		t.Errorf("expected empty Href, got '%s'", link.Href)
 	}
 }
+
+func TestEmulation_Merge(t *testing.T) {
+	base := &Emulation{
+		Device:      "iphone-x",
+		Landscape:   true,
+		Locale:      "ru-RU",
+		Timezone:    "Europe/Moscow",
+		Geolocation: &Geolocation{Latitude: 55.75, Longitude: 37.62},
+	}
+
+	got := base.Merge(&Emulation{Locale: "en-US", ColorScheme: "dark"})
+	if got.Device != "iphone-x" || !got.Landscape || got.Locale != "en-US" || got.Timezone != "Europe/Moscow" || got.ColorScheme != "dark" {
+		t.Errorf("Merge() = %+v", got)
+	}
+	if got.Geolocation == nil || got.Geolocation.Latitude != 55.75 {
+		t.Errorf("Merge() geolocation = %+v", got.Geolocation)
+	}
+
+	// Ориентация задачи относится к её устройству
+	got = base.Merge(&Emulation{Device: "pixel-2"})
+	if got.Device != "pixel-2" || got.Landscape {
+		t.Errorf("Merge() with device = %+v", got)
+	}
+
+	// База не меняется, nil с обеих сторон даёт пустые настройки
+	if base.Locale != "ru-RU" {
+		t.Errorf("base changed: %+v", base)
+	}
+	var none *Emulation
+	if got := none.Merge(nil); got == nil || *got != (Emulation{}) {
+		t.Errorf("nil.Merge(nil) = %+v", got)
+	}
+}
@@ -0,0 +1,33 @@
+package types
+
+import "time"
+
+// SessionState — переносимое состояние входа на сайты: cookies и хранилища страниц.
+// В отличие от каталога профиля Chrome не зависит от машины и версии браузера.
+type SessionState struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	// Domains — домены, которыми ограничен экспорт; пусто — все
+	Domains []string        `json:"domains,omitempty"`
+	Cookies []SessionCookie `json:"cookies"`
+	Origins []OriginStorage `json:"origins"`
+}
+
+// SessionCookie — cookie браузера. Expires — Unix-время в секундах, 0 у сессионных cookie.
+type SessionCookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires,omitempty"`
+	HTTPOnly bool    `json:"http_only,omitempty"`
+	Secure   bool    `json:"secure,omitempty"`
+	SameSite string  `json:"same_site,omitempty"`
+}
+
+// OriginStorage — localStorage и sessionStorage одного origin (https://mail.ru)
+type OriginStorage struct {
+	Origin         string            `json:"origin"`
+	LocalStorage   map[string]string `json:"local_storage,omitempty"`
+	SessionStorage map[string]string `json:"session_storage,omitempty"`
+}
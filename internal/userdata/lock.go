@@ -0,0 +1,107 @@
+package userdata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// LockFile — файл блокировки в каталоге данных браузера с PID процесса агента
+const LockFile = ".agent.lock"
+
+// ErrLocked — каталог данных уже используется другим процессом агента
+var ErrLocked = errors.New("browser profile is in use by another agent process")
+
+// Lock — блокировка каталога данных браузера. Chrome, запущенный дважды на одном
+// каталоге, молча портит профиль, поэтому второй запуск должен получить ошибку.
+type Lock struct {
+	path string
+	pid  int
+}
+
+// Acquire блокирует каталог данных (создаёт его при необходимости). Блокировка
+// завершившегося процесса считается устаревшей и снимается.
+func Acquire(dir string) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create user data dir: %w", err)
+	}
+
+	path := filepath.Join(dir, LockFile)
+	pid := os.Getpid()
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_, err = f.WriteString(strconv.Itoa(pid))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("write lock file: %w", err)
+			}
+			return &Lock{path: path, pid: pid}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create lock file: %w", err)
+		}
+
+		if owner, locked := IsLocked(dir); locked {
+			return nil, fmt.Errorf("%w: %s (pid %d)", ErrLocked, dir, owner)
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("remove stale lock file: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrLocked, dir)
+}
+
+// Release снимает блокировку, если она всё ещё принадлежит этому процессу
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	if owner, ok := readLock(l.path); !ok || owner != l.pid {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove lock file: %w", err)
+	}
+	return nil
+}
+
+// IsLocked возвращает PID процесса, заблокировавшего каталог. Блокировка
+// текущего процесса тоже считается занятой: второй Manager на том же каталоге недопустим.
+func IsLocked(dir string) (int, bool) {
+	pid, ok := readLock(filepath.Join(dir, LockFile))
+	if !ok {
+		return 0, false
+	}
+	return pid, processAlive(pid)
+}
+
+func readLock(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
+
+// processAlive проверяет процесс сигналом 0. EPERM значит, что процесс есть, но принадлежит
+// другому пользователю.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,285 @@
+// Package userdata управляет именованными профилями браузера: каталогами данных Chrome
+// под общим корнем с метаданными и блокировкой от одновременного запуска.
+package userdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetaFile — файл метаданных в каталоге профиля. Chrome не трогает посторонние файлы каталога.
+const MetaFile = "agent-profile.json"
+
+var (
+	ErrNotFound    = errors.New("browser profile not found")
+	ErrExists      = errors.New("browser profile already exists")
+	ErrInvalidName = errors.New("invalid profile name: use letters, digits, '.', '_' and '-'")
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Meta — метаданные профиля
+type Meta struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsed — время последнего запуска агента на профиле; нулевое, если профиль не запускался
+	LastUsed time.Time `json:"last_used"`
+	// Domains — сайты, на которые выполнен вход (по cookies на момент последнего завершения)
+	Domains []string `json:"domains,omitempty"`
+	// Proxy — прокси браузера профиля (URL, может содержать логин и пароль); пусто — без прокси
+	Proxy string `json:"proxy,omitempty"`
+}
+
+// Profile — профиль браузера: метаданные и каталог данных Chrome
+type Profile struct {
+	Meta
+	Dir string
+}
+
+// Store — корень с профилями: каждый профиль — подкаталог с именем профиля
+type Store struct {
+	root string
+}
+
+// NewStore возвращает хранилище профилей в каталоге root
+func NewStore(root string) *Store {
+	return &Store{root: root}
+}
+
+// Dir возвращает каталог данных профиля
+func (s *Store) Dir(name string) string {
+	return filepath.Join(s.root, name)
+}
+
+// List возвращает профили, отсортированные по имени. Каталоги без метаданных пропускаются.
+func (s *Store) List() ([]Profile, error) {
+	entries, err := os.ReadDir(s.root)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read profiles dir: %w", err)
+	}
+
+	var list []Profile
+	for _, e := range entries {
+		if !e.IsDir() || !validName.MatchString(e.Name()) {
+			continue
+		}
+		p, err := s.Get(e.Name())
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Get возвращает профиль по имени
+func (s *Store) Get(name string) (*Profile, error) {
+	if !validName.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+
+	dir := s.Dir(name)
+	data, err := os.ReadFile(filepath.Join(dir, MetaFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read profile %s: %w", name, err)
+	}
+
+	var meta Meta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("parse profile %s metadata: %w", name, err)
+	}
+	// Имя определяется каталогом: переименованный вручную профиль остаётся рабочим
+	meta.Name = name
+	return &Profile{Meta: meta, Dir: dir}, nil
+}
+
+// Create создаёт пустой профиль
+func (s *Store) Create(name string) (*Profile, error) {
+	dir, err := s.newDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Profile{Meta: Meta{Name: name, CreatedAt: time.Now().UTC()}, Dir: dir}
+	if err := writeMeta(p); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return p, nil
+}
+
+// Clone копирует профиль src в новый профиль dst вместе со входами на сайты.
+// Запущенный профиль не копируется: файлы Chrome в нём могут быть записаны наполовину.
+func (s *Store) Clone(src, dst string) (*Profile, error) {
+	from, err := s.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	if pid, locked := IsLocked(from.Dir); locked {
+		return nil, fmt.Errorf("%w: %s (pid %d)", ErrLocked, src, pid)
+	}
+
+	dir, err := s.newDir(dst)
+	if err != nil {
+		return nil, err
+	}
+	if err := copyDir(from.Dir, dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("copy profile %s: %w", src, err)
+	}
+
+	p := &Profile{
+		// Прокси копируется: сайты могут привязывать вход к адресу, с которого он выполнен
+		Meta: Meta{Name: dst, CreatedAt: time.Now().UTC(), Domains: from.Domains, Proxy: from.Proxy},
+		Dir:  dir,
+	}
+	if err := writeMeta(p); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return p, nil
+}
+
+// Delete удаляет профиль со всеми данными. Запущенный профиль не удаляется.
+func (s *Store) Delete(name string) error {
+	p, err := s.Get(name)
+	if err != nil {
+		return err
+	}
+	if pid, locked := IsLocked(p.Dir); locked {
+		return fmt.Errorf("%w: %s (pid %d)", ErrLocked, name, pid)
+	}
+	if err := os.RemoveAll(p.Dir); err != nil {
+		return fmt.Errorf("delete profile %s: %w", name, err)
+	}
+	return nil
+}
+
+// Touch отмечает запуск профиля. Непустой domains заменяет список сайтов со входом.
+func (s *Store) Touch(name string, domains []string) error {
+	p, err := s.Get(name)
+	if err != nil {
+		return err
+	}
+	p.LastUsed = time.Now().UTC()
+	if domains != nil {
+		p.Domains = domains
+	}
+	return writeMeta(p)
+}
+
+// SetProxy задаёт прокси профиля; пустая строка убирает его
+func (s *Store) SetProxy(name, proxy string) error {
+	p, err := s.Get(name)
+	if err != nil {
+		return err
+	}
+	p.Proxy = proxy
+	return writeMeta(p)
+}
+
+// newDir создаёт каталог нового профиля, если профиля с таким именем ещё нет
+func (s *Store) newDir(name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", ErrInvalidName
+	}
+	if err := os.MkdirAll(s.root, 0o700); err != nil {
+		return "", fmt.Errorf("create profiles dir: %w", err)
+	}
+
+	dir := s.Dir(name)
+	// Mkdir без All: существующий каталог — ошибка, а не тихое слияние с чужими данными
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("%w: %s", ErrExists, name)
+		}
+		return "", fmt.Errorf("create profile %s: %w", name, err)
+	}
+	return dir, nil
+}
+
+func writeMeta(p *Profile) error {
+	data, err := json.MarshalIndent(p.Meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal profile metadata: %w", err)
+	}
+
+	// Запись через временный файл: прерванный запуск не оставит битые метаданные
+	path := filepath.Join(p.Dir, MetaFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write profile metadata: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write profile metadata: %w", err)
+	}
+	return nil
+}
+
+// copyDir копирует каталог профиля. Пропускаются блокировка агента и ссылки Chrome
+// (SingletonLock и т.п.): они относятся к запущенному процессу, а не к данным.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if rel == LockFile || rel == MetaFile || strings.HasPrefix(d.Name(), "Singleton") {
+			return nil
+		}
+
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o700)
+		case d.Type().IsRegular():
+			return copyFile(path, target)
+		default:
+			return nil
+		}
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}